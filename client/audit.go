@@ -0,0 +1,31 @@
+package client
+
+import "time"
+
+// AuditEvent records one operation performed by a Client for WithAuditSink.
+// It never carries the secret or the value.
+type AuditEvent struct {
+	// Op is the operation: "retrieve", "store", "list", "store_transaction",
+	// "store_large" or "retrieve_large".
+	Op string
+	// Key is the key as given by the caller, or the prefix for "list".
+	Key        string
+	Timestamp  time.Time
+	Err        error
+	DurationMS int64
+}
+
+// audit reports an operation on key that began at start and ended with err to
+// the WithAuditSink sink, if any.
+func (c *Client) audit(op, key string, start time.Time, err error) {
+	if c.auditSink == nil {
+		return
+	}
+	c.auditSink(AuditEvent{
+		Op:         op,
+		Key:        key,
+		Timestamp:  start,
+		Err:        err,
+		DurationMS: time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff describes an exponential backoff policy for retrying calls. It is
+// used by WithBackoff and by TLSConfig.CAReadBackoff.
+type Backoff struct {
+	// MaxRetries is how many times a failed attempt is retried; zero means
+	// no retries.
+	MaxRetries int
+	// Base is the delay before the first retry.
+	Base time.Duration
+	// Max caps the delay; zero means no cap.
+	Max time.Duration
+	// Factor multiplies the delay after every attempt; zero means 2.
+	Factor float64
+	// Jitter randomizes each delay by up to ±Jitter of its value, between
+	// 0 and 1, so that many clients retrying together spread out.
+	Jitter float64
+}
+
+// Next returns the delay to wait before retry number attempt, counting from
+// zero.
+func (b Backoff) Next(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	factor := b.Factor
+	if factor == 0 {
+		factor = 2
+	}
+
+	delay := float64(b.Base) * math.Pow(factor, float64(attempt))
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// retry calls fn until it succeeds, returns an error retryable rejects, or
+// MaxRetries retries have been made, waiting Next between attempts. Waiting
+// stops early when ctx ends, returning fn's last error.
+func (b Backoff) retry(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	err := fn()
+	for attempt := 0; err != nil && attempt < b.MaxRetries && retryable(err); attempt++ {
+		timer := time.NewTimer(b.Next(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		err = fn()
+	}
+	return err
+}
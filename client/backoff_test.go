@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackoffNext(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for attempt, w := range want {
+		if got := b.Next(attempt); got != w {
+			t.Errorf("Next(%d) = %s, want %s", attempt, got, w)
+		}
+	}
+
+	b = Backoff{Base: time.Second, Factor: 3}
+	if got := b.Next(2); got != 9*time.Second {
+		t.Errorf("Next(2) with factor 3 = %s, want 9s", got)
+	}
+	b = Backoff{Base: time.Hour}
+	if got := b.Next(1000); got <= 0 {
+		t.Errorf("Next(1000) without a cap = %s, want a positive delay", got)
+	}
+}
+
+func TestBackoffJitterStaysInBounds(t *testing.T) {
+	b := Backoff{Base: 100 * time.Millisecond, Jitter: 0.2}
+	for attempt := 0; attempt < 4; attempt++ {
+		nominal := Backoff{Base: b.Base}.Next(attempt)
+		low, high := nominal*8/10, nominal*12/10
+		for i := 0; i < 100; i++ {
+			if got := b.Next(attempt); got < low || got > high {
+				t.Fatalf("Next(%d) = %s, want within [%s, %s]", attempt, got, low, high)
+			}
+		}
+	}
+}
+
+func TestWithBackoffRetriesOutages(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	s.beforeRetrieve = func(context.Context, *pb.RetrieveRequest) error {
+		if s.retrieves.Load() <= 2 {
+			return status.Error(codes.Unavailable, "starting up")
+		}
+		return nil
+	}
+	c := s.newClient(t, WithBackoff(Backoff{MaxRetries: 3, Base: time.Millisecond}))
+
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Fatalf("Retrieve = %q, %v", value, err)
+	}
+	if n := s.retrieves.Load(); n != 3 {
+		t.Errorf("server saw %d retrieves, want 3", n)
+	}
+
+	s.retrieves.Store(100)
+	if _, err := c.Retrieve("missing", testSecret); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Retrieve of missing key = %v, want ErrNotFound", err)
+	}
+	if n := s.retrieves.Load(); n != 101 {
+		t.Errorf("ErrNotFound was retried: server saw %d retrieves, want 1", n-100)
+	}
+}
+
+func TestWithBackoffGivesUp(t *testing.T) {
+	s := startMockServer(t)
+	s.down.Store(true)
+	c := s.newClient(t, WithBackoff(Backoff{MaxRetries: 2, Base: time.Millisecond}))
+
+	if err := c.Store("key", testSecret, "value"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Store = %v, want ErrUnavailable", err)
+	}
+	if _, err := NewClient("localhost", 8443, WithBackoff(Backoff{MaxRetries: -1})); err == nil {
+		t.Error("WithBackoff accepted negative MaxRetries")
+	}
+}
+
+func TestWithBackoffRetriesDialFailures(t *testing.T) {
+	var dials atomic.Int32
+	refuse := func(context.Context, string) (net.Conn, error) {
+		dials.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	c, err := NewClient("bufnet", 1,
+		WithContextDialer(refuse),
+		WithDialTimeout(50*time.Millisecond),
+		WithBackoff(Backoff{MaxRetries: 2, Base: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.Timeout = 5 * time.Second
+
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Retrieve = %v, want ErrUnavailable", err)
+	}
+	if n := dials.Load(); n < 3 {
+		t.Errorf("dialer called %d times, want at least one dial per attempt", n)
+	}
+}
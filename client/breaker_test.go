@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithCircuitBreaker(2, 100*time.Millisecond))
+
+	s.down.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Retrieve %d = %v, want ErrUnavailable", i, err)
+		}
+	}
+
+	// The breaker is open: calls fail without reaching the server.
+	calls := s.retrieves.Load()
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Retrieve with open breaker = %v, want ErrCircuitOpen", err)
+	}
+	if s.retrieves.Load() != calls {
+		t.Error("open breaker let a call through to the server")
+	}
+
+	// A failed trial call after the cooldown opens it again.
+	time.Sleep(150 * time.Millisecond)
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("trial Retrieve = %v, want ErrUnavailable", err)
+	}
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Retrieve after failed trial = %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful trial call closes it.
+	s.down.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+			t.Fatalf("Retrieve after recovery = %q, %v", value, err)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresServerAnswers(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t, WithCircuitBreaker(1, time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Retrieve("missing", testSecret); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Retrieve %d = %v, want ErrNotFound", i, err)
+		}
+	}
+}
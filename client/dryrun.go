@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxDryRunMessageBytes is gRPC's default maximum receive message size, which
+// the server applies to Store requests unless configured otherwise.
+const maxDryRunMessageBytes = 4 << 20
+
+// StoreDryRun checks that Store(key, secret, value) would be accepted,
+// without writing anything: the client must not be read-only, the key must
+// be non-empty, and the Store request, with the key prefix applied and the
+// value passed through any encoder and KeyProvider, must fit in gRPC's
+// default 4 MiB message limit. It then sends a Retrieve for key to check
+// connectivity and the secret, discarding the response; ErrNotFound counts
+// as success, since the key need not exist yet.
+func (c *Client) StoreDryRun(key, secret, value string) error {
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if key == "" {
+		return errors.New("key must not be empty")
+	}
+
+	ctx := context.Background()
+	encoded, err := c.encodeValue(ctx, key, value)
+	if err != nil {
+		return err
+	}
+	_, password := c.authenticate(ctx, secret)
+	request := &pb.StoreRequest{Key: c.keyPrefix + key, Value: encoded, Password: password}
+	if size := proto.Size(request); size > maxDryRunMessageBytes {
+		return fmt.Errorf("store request for key %q is %d bytes, the limit is %d", key, size, maxDryRunMessageBytes)
+	}
+
+	err = c.invoke(ctx, func(ctx context.Context, client pb.ParameterStoreClient) error {
+		ctx, password := c.authenticate(ctx, secret)
+		_, err := client.Retrieve(ctx, &pb.RetrieveRequest{
+			Key:      c.keyPrefix + key,
+			Password: password,
+		})
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("store preflight for key %q failed: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStoreDryRun(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t, WithKeyPrefix("svc/"))
+
+	if err := c.StoreDryRun("new-key", testSecret, "value"); err != nil {
+		t.Errorf("StoreDryRun of a new key: %v", err)
+	}
+	s.set("svc/existing", "old")
+	if err := c.StoreDryRun("existing", testSecret, "value"); err != nil {
+		t.Errorf("StoreDryRun of an existing key: %v", err)
+	}
+	if n := s.stores.Load(); n != 0 {
+		t.Errorf("StoreDryRun wrote %d values, want 0", n)
+	}
+	if value, _ := s.get("svc/existing"); value != "old" {
+		t.Errorf("existing value changed to %q", value)
+	}
+	if _, ok := s.get("svc/new-key"); ok {
+		t.Error("StoreDryRun created the key")
+	}
+}
+
+func TestStoreDryRunValidation(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	if err := c.StoreDryRun("", testSecret, "value"); err == nil {
+		t.Error("StoreDryRun accepted an empty key")
+	}
+	if err := c.StoreDryRun("key", "wrong", "value"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("StoreDryRun with wrong secret = %v, want ErrUnauthenticated", err)
+	}
+	tooLarge := strings.Repeat("x", maxDryRunMessageBytes)
+	if err := c.StoreDryRun("key", testSecret, tooLarge); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("StoreDryRun of an oversized value = %v, want a size error", err)
+	}
+	if n := s.stores.Load(); n != 0 {
+		t.Errorf("StoreDryRun wrote %d values, want 0", n)
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ParameterStoreRetriever retrieves values by key using credentials bound at
+// construction time.
+type ParameterStoreRetriever interface {
+	Retrieve(key string) (string, error)
+}
+
+// DualTransportRetriever retrieves values over gRPC and REST, falling back to
+// the secondary transport only when the primary one cannot be reached.
+// Authentication and not-found errors are returned without falling back.
+type DualTransportRetriever struct {
+	grpcClient *Client
+	restClient *APIClient
+	secret     string
+	preferREST bool
+}
+
+type DualTransportOption func(*DualTransportRetriever)
+
+// PreferREST makes REST the primary transport and gRPC the fallback.
+func PreferREST() DualTransportOption {
+	return func(r *DualTransportRetriever) {
+		r.preferREST = true
+	}
+}
+
+// NewDualTransportRetriever creates a retriever for the gRPC server at
+// grpcTarget (host:port) and the REST server at restBaseURL. tlsConfig
+// secures the gRPC connection and may be nil.
+func NewDualTransportRetriever(grpcTarget, restBaseURL, secret string, tlsConfig *TLSConfig, opts ...DualTransportOption) (*DualTransportRetriever, error) {
+	host, portStr, err := net.SplitHostPort(grpcTarget)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gRPC target %q: %w", grpcTarget, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in gRPC target %q: %w", grpcTarget, err)
+	}
+
+	grpcClient, err := NewClient(host, port)
+	if err != nil {
+		return nil, err
+	}
+	grpcClient.TLSConfig = tlsConfig
+	if err := grpcClient.validate(); err != nil {
+		return nil, err
+	}
+
+	r := &DualTransportRetriever{
+		grpcClient: grpcClient,
+		restClient: NewAPIClient(restBaseURL, secret),
+		secret:     secret,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+func (r *DualTransportRetriever) String() string {
+	return fmt.Sprintf("DualTransportRetriever{grpc:%v rest:%v secret:%s preferREST:%t}",
+		r.grpcClient, r.restClient, redact(r.secret), r.preferREST)
+}
+
+func (r *DualTransportRetriever) Retrieve(key string) (string, error) {
+	primary, fallback := r.retrieveGrpc, r.retrieveREST
+	if r.preferREST {
+		primary, fallback = fallback, primary
+	}
+
+	value, err := primary(key)
+	if err == nil || !isConnectionError(err) {
+		return value, err
+	}
+	value, fallbackErr := fallback(key)
+	if fallbackErr != nil {
+		return "", fmt.Errorf("both transports failed: %w", errors.Join(err, fallbackErr))
+	}
+	return value, nil
+}
+
+func (r *DualTransportRetriever) retrieveGrpc(key string) (string, error) {
+	return r.grpcClient.Retrieve(key, r.secret)
+}
+
+func (r *DualTransportRetriever) retrieveREST(key string) (string, error) {
+	return r.restClient.Retrieve(key)
+}
+
+// isConnectionError reports whether err means the server could not be
+// reached, as opposed to the server answering with an error.
+func isConnectionError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	return status.Code(err) == codes.Unavailable
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDualTransportFallsBackToREST(t *testing.T) {
+	var restCalls atomic.Int32
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		restCalls.Add(1)
+		if r.URL.Query().Get("key") != "key" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"value": "from rest"}`))
+	}))
+	defer rest.Close()
+
+	// Nothing listens on the gRPC port.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	downTarget := lis.Addr().String()
+	lis.Close()
+
+	r, err := NewDualTransportRetriever(downTarget, rest.URL, testSecret, nil)
+	if err != nil {
+		t.Fatalf("NewDualTransportRetriever: %v", err)
+	}
+	if value, err := r.Retrieve("key"); err != nil || value != "from rest" {
+		t.Errorf("Retrieve with gRPC down = %q, %v; want the REST value", value, err)
+	}
+	if restCalls.Load() != 1 {
+		t.Errorf("REST called %d times, want 1", restCalls.Load())
+	}
+}
+
+func TestDualTransportNoFallbackOnServerAnswer(t *testing.T) {
+	var restCalls atomic.Int32
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		restCalls.Add(1)
+		w.Write([]byte(`{"value": "from rest"}`))
+	}))
+	defer rest.Close()
+
+	s := newMockServer()
+	s.set("key", "from grpc")
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serve(t, s, lis)
+	target := net.JoinHostPort("127.0.0.1", strconv.Itoa(lis.Addr().(*net.TCPAddr).Port))
+
+	r, err := NewDualTransportRetriever(target, rest.URL, testSecret, nil)
+	if err != nil {
+		t.Fatalf("NewDualTransportRetriever: %v", err)
+	}
+	if value, err := r.Retrieve("key"); err != nil || value != "from grpc" {
+		t.Errorf("Retrieve = %q, %v; want the gRPC value", value, err)
+	}
+	if _, err := r.Retrieve("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Retrieve of missing key = %v, want ErrNotFound", err)
+	}
+	if restCalls.Load() != 0 {
+		t.Errorf("REST called %d times, want no fallback", restCalls.Load())
+	}
+
+	preferREST, err := NewDualTransportRetriever(target, rest.URL, testSecret, nil, PreferREST())
+	if err != nil {
+		t.Fatalf("NewDualTransportRetriever: %v", err)
+	}
+	if value, err := preferREST.Retrieve("key"); err != nil || value != "from rest" {
+		t.Errorf("Retrieve with PreferREST = %q, %v; want the REST value", value, err)
+	}
+}
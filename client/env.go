@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewClientFromEnv builds a Client from environment variables named with the
+// given prefix (an underscore is added between prefix and name):
+//
+//	PREFIX_HOST         server host name, address or unix:// socket (required)
+//	PREFIX_PORT         server port (required unless HOST is a unix socket)
+//	PREFIX_TIMEOUT      per-call timeout as a Go duration, e.g. "5s" (optional)
+//	PREFIX_CLIENT_CERT  client certificate PEM file for mutual TLS (optional)
+//	PREFIX_CLIENT_KEY   client private key PEM file for mutual TLS (optional)
+//	PREFIX_CA_CERT      CA bundle used to verify the server (optional)
+//	PREFIX_SERVER_NAME  expected server name for TLS verification (optional)
+//
+// TLS is enabled when any of the certificate variables or SERVER_NAME is set,
+// verifying the server against the system roots if CA_CERT is not set;
+// otherwise the connection is insecure.
+func NewClientFromEnv(prefix string) (*Client, error) {
+	env := func(name string) string {
+		return os.Getenv(prefix + "_" + name)
+	}
+
+	host := env("HOST")
+	if host == "" {
+		return nil, fmt.Errorf("%s_HOST is not set", prefix)
+	}
+	var port int
+	var err error
+	if raw := env("PORT"); raw != "" || !strings.HasPrefix(host, "unix:") {
+		port, err = strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_PORT: %w", prefix, err)
+		}
+	}
+
+	c := &Client{Host: host, Port: port}
+
+	if raw := env("TIMEOUT"); raw != "" {
+		c.Timeout, err = time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_TIMEOUT: %w", prefix, err)
+		}
+	}
+
+	certFile, keyFile, caFile := env("CLIENT_CERT"), env("CLIENT_KEY"), env("CA_CERT")
+	serverName := env("SERVER_NAME")
+	if certFile != "" || keyFile != "" || caFile != "" || serverName != "" {
+		c.TLSConfig = &TLSConfig{
+			ClientCertFile: certFile,
+			ClientKeyFile:  keyFile,
+			CAFile:         caFile,
+			ServerName:     serverName,
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
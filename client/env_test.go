@@ -0,0 +1,93 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewClientFromEnv(t *testing.T) {
+	// Validation only checks that the TLS files exist.
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt")
+	for _, path := range []string{certFile, keyFile, caFile} {
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	t.Setenv("PS_HOST", "store.example.com")
+	t.Setenv("PS_PORT", "8443")
+	t.Setenv("PS_TIMEOUT", "3s")
+	t.Setenv("PS_CLIENT_CERT", certFile)
+	t.Setenv("PS_CLIENT_KEY", keyFile)
+	t.Setenv("PS_CA_CERT", caFile)
+	t.Setenv("PS_SERVER_NAME", "store.internal")
+
+	c, err := NewClientFromEnv("PS")
+	if err != nil {
+		t.Fatalf("NewClientFromEnv: %v", err)
+	}
+	if c.Host != "store.example.com" || c.Port != 8443 || c.Timeout != 3*time.Second {
+		t.Errorf("client = %v", c)
+	}
+	got := c.TLSConfig
+	if got == nil || got.ClientCertFile != certFile || got.ClientKeyFile != keyFile || got.CAFile != caFile || got.ServerName != "store.internal" {
+		t.Errorf("TLSConfig = %+v, want the files and server name from the environment", got)
+	}
+}
+
+func TestNewClientFromEnvTLSModes(t *testing.T) {
+	t.Setenv("PS_HOST", "store.example.com")
+	t.Setenv("PS_PORT", "8443")
+
+	c, err := NewClientFromEnv("PS")
+	if err != nil {
+		t.Fatalf("NewClientFromEnv: %v", err)
+	}
+	if c.TLSConfig != nil {
+		t.Errorf("TLSConfig = %v without TLS variables, want nil", c.TLSConfig)
+	}
+
+	t.Setenv("PS_SERVER_NAME", "store.internal")
+	c, err = NewClientFromEnv("PS")
+	if err != nil {
+		t.Fatalf("NewClientFromEnv: %v", err)
+	}
+	if c.TLSConfig == nil || c.TLSConfig.ServerName != "store.internal" || c.TLSConfig.CAFile != "" {
+		t.Errorf("TLSConfig = %v with only SERVER_NAME, want system-root TLS for store.internal", c.TLSConfig)
+	}
+}
+
+func TestNewClientFromEnvUnixSocket(t *testing.T) {
+	t.Setenv("PS_HOST", "unix:///var/run/ps.sock")
+
+	c, err := NewClientFromEnv("PS")
+	if err != nil {
+		t.Fatalf("NewClientFromEnv: %v", err)
+	}
+	if c.address() != "unix:///var/run/ps.sock" {
+		t.Errorf("address = %q", c.address())
+	}
+}
+
+func TestNewClientFromEnvErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"missing host":      {"PS_PORT": "8443"},
+		"missing port":      {"PS_HOST": "localhost"},
+		"invalid port":      {"PS_HOST": "localhost", "PS_PORT": "https"},
+		"port out of range": {"PS_HOST": "localhost", "PS_PORT": "70000"},
+		"invalid timeout":   {"PS_HOST": "localhost", "PS_PORT": "8443", "PS_TIMEOUT": "soon"},
+		"cert without key":  {"PS_HOST": "localhost", "PS_PORT": "8443", "PS_CLIENT_CERT": "client.crt"},
+	}
+	for name, env := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"PS_HOST", "PS_PORT", "PS_TIMEOUT", "PS_CLIENT_CERT"} {
+				t.Setenv(key, env[key])
+			}
+			if _, err := NewClientFromEnv("PS"); err == nil {
+				t.Error("NewClientFromEnv succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Both the gRPC Client and the REST APIClient wrap server errors with these
+// sentinels, so errors.Is works the same regardless of transport. gRPC errors
+// keep their status, so status.Code still reports the original code.
+var (
+	ErrNotFound        = errors.New("parameter not found")
+	ErrUnauthenticated = errors.New("not authorized to access parameter")
+	ErrUnavailable     = errors.New("parameter store unavailable")
+)
+
+var (
+	// ErrEmptyValue is returned by RetrieveNonEmpty when the key exists but
+	// its value is the empty string.
+	ErrEmptyValue = errors.New("parameter store returned an empty value")
+	// ErrRateLimited is returned when the client-side rate limit would delay
+	// a call past its context deadline or the context is cancelled while
+	// waiting.
+	ErrRateLimited = errors.New("rate limit wait aborted")
+	// ErrVerificationFailed is returned by StoreVerified when the value read
+	// back differs from the value stored.
+	ErrVerificationFailed = errors.New("stored value did not round-trip intact")
+	// ErrReadOnly is returned by mutating methods of a Client created with
+	// WithReadOnly.
+	ErrReadOnly = errors.New("client is read-only")
+	// ErrClientClosed is returned by calls started after Client.Close.
+	ErrClientClosed = errors.New("client is closed")
+	// ErrResponseTooLarge is returned by APIClient.Retrieve when the response
+	// body exceeds the WithMaxResponseBytes limit.
+	ErrResponseTooLarge = errors.New("response body too large")
+	// ErrCircuitOpen is returned without contacting the server while the
+	// WithCircuitBreaker breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrStreamEncryption is returned by StoreLarge and RetrieveLarge on a
+	// client with WithKeyProvider, which only encrypts whole values.
+	ErrStreamEncryption = errors.New("streaming is not supported with a key provider")
+)
+
+func normalizeGrpcError(err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return err
+	case codes.NotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// httpStatusError maps a non-success HTTP status to one of the sentinels,
+// falling back to fallback for statuses without a gRPC equivalent.
+func httpStatusError(statusCode int, fallback error) error {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: HTTP %d", ErrNotFound, statusCode)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: HTTP %d", ErrUnauthenticated, statusCode)
+	case statusCode >= 500:
+		return fmt.Errorf("%w: HTTP %d", ErrUnavailable, statusCode)
+	}
+	return fallback
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+)
+
+// ExecWithParams retrieves every parameter in keys, which maps environment
+// variable names to parameter keys, and runs argv with those variables added
+// to the current environment. The command inherits stdin, stdout and stderr.
+//
+// If the command exits with a non-zero status the returned error is an
+// *exec.ExitError, whose ExitCode the caller can pass to os.Exit. Parameter
+// values never appear in returned errors.
+func ExecWithParams(ctx context.Context, c *Client, secret string, keys map[string]string, argv []string) error {
+	if len(argv) == 0 {
+		return errors.New("no command given")
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := os.Environ()
+	for _, name := range names {
+		value, err := c.RetrieveContext(ctx, keys[name], secret)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve key %q for %s: %w", keys[name], name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExecWithParams(t *testing.T) {
+	s := startMockServer(t)
+	s.set("db/password", "hunter2")
+	c := s.newClient(t)
+	keys := map[string]string{"DB_PASSWORD": "db/password"}
+
+	err := ExecWithParams(context.Background(), c, testSecret, keys, []string{"sh", "-c", `test "$DB_PASSWORD" = hunter2`})
+	if err != nil {
+		t.Errorf("command did not see the parameter: %v", err)
+	}
+
+	err = ExecWithParams(context.Background(), c, testSecret, keys, []string{"sh", "-c", "exit 3"})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("ExecWithParams = %v, want exit status 3", err)
+	}
+
+	err = ExecWithParams(context.Background(), c, testSecret, map[string]string{"X": "missing"}, []string{"true"})
+	if !errors.Is(err, ErrNotFound) || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("ExecWithParams with a missing key = %v, want ErrNotFound", err)
+	}
+	if err := ExecWithParams(context.Background(), c, testSecret, keys, nil); err == nil {
+		t.Error("ExecWithParams without a command succeeded")
+	}
+}
@@ -2,14 +2,945 @@ package client
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
 
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
+// Client is a reusable gRPC client for the parameter store. Unlike the
+// GrpcSimple* helpers it returns errors instead of logging them.
+//
+// Host may also be a unix socket address such as unix:///var/run/ps.sock, in
+// which case Port is ignored.
+//
+// A zero Timeout leaves the caller's context untouched, unless
+// WithDeadlinePropagation is set; otherwise every RPC is bounded by it.
+// Connection establishment is bounded separately by WithDialTimeout. A nil
+// TLSConfig means the connection is insecure.
+type Client struct {
+	Host      string
+	Port      int
+	Timeout   time.Duration
+	TLSConfig *TLSConfig
+
+	secretMu sync.RWMutex
+	secret   string
+
+	// closeMu guards closed and orders inflight.Add before Close waits.
+	closeMu  sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+
+	dialOptions []grpc.DialOption
+	// resolverScheme is the scheme of the WithResolver resolver, used as
+	// the scheme of the dial target.
+	resolverScheme string
+
+	limiter     *rate.Limiter
+	breaker     *circuitBreaker
+	backoff     Backoff
+	dialTimeout time.Duration
+	// retrieveGroup coalesces concurrent identical retrievals when
+	// WithSingleFlight is set.
+	retrieveGroup *singleflight.Group
+	keyPrefix     string
+	pool          *connPool
+
+	strictCertValidation bool
+	secretHeader         string
+	slowThreshold        time.Duration
+	readOnly             bool
+	propagateDeadline    bool
+	interpolation        bool
+
+	auditSink func(AuditEvent)
+
+	valueTransformer func(key, raw string) (string, error)
+	valueEncoder     func(key, value string) (string, error)
+	keyProvider      KeyProvider
+
+	metadataFromContext func(context.Context) map[string]string
+}
+
+// defaultPropagatedDeadline bounds calls made with WithDeadlinePropagation
+// when neither Timeout nor the caller's context sets a deadline.
+const defaultPropagatedDeadline = 30 * time.Second
+
+// NewClient creates a Client for host:port, applies opts and validates the
+// result.
+func NewClient(host string, port int, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		Host: host,
+		Port: port,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if c.TLSConfig != nil && len(c.TLSConfig.P12Bytes) > 0 {
+		// Decode the bundle now so the password callback runs once, before
+		// any call's timeout starts, rather than inside the first dial.
+		if _, err := c.TLSConfig.GetTLSConfig(); err != nil {
+			return nil, fmt.Errorf("failed to build TLS config: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// String implements fmt.Stringer so that printing a Client with %v or %+v
+// never reveals the secret set with SetSecret.
+func (c *Client) String() string {
+	return fmt.Sprintf("Client{Host:%s Port:%d Timeout:%s TLSConfig:%+v secret:%s}",
+		c.Host, c.Port, c.Timeout, c.TLSConfig, redact(c.currentSecret()))
+}
+
+// Describe returns a human-readable summary of the effective connection
+// settings for diagnosing connection failures. It never includes the secret
+// or any key material.
+func (c *Client) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "target: %s\n", c.address())
+	fmt.Fprintf(&b, "timeout: %s\n", c.Timeout)
+	if c.dialTimeout > 0 {
+		fmt.Fprintf(&b, "dial timeout: %s\n", c.dialTimeout)
+	}
+
+	serverName := ""
+	switch {
+	case c.TLSConfig != nil && len(c.TLSConfig.P12Bytes) > 0:
+		b.WriteString("TLS mode: P12 data\n")
+		serverName = c.TLSConfig.ServerName
+	case c.TLSConfig != nil && c.TLSConfig.ClientCertFile != "":
+		fmt.Fprintf(&b, "TLS mode: separate files (cert %s, key %s, CA %s)\n",
+			c.TLSConfig.ClientCertFile, c.TLSConfig.ClientKeyFile, caDescription(c.TLSConfig.CAFile))
+		serverName = c.TLSConfig.ServerName
+	case c.TLSConfig != nil:
+		fmt.Fprintf(&b, "TLS mode: server-only (CA %s)\n", caDescription(c.TLSConfig.CAFile))
+		serverName = c.TLSConfig.ServerName
+	default:
+		b.WriteString("TLS mode: insecure\n")
+	}
+	if c.TLSConfig != nil {
+		if serverName == "" {
+			serverName = "(from target)"
+		}
+		fmt.Fprintf(&b, "TLS server name: %s\n", serverName)
+		b.WriteString("TLS min version: 1.2\n")
+	}
+
+	if c.keyPrefix != "" {
+		fmt.Fprintf(&b, "key prefix: %s\n", c.keyPrefix)
+	}
+	if c.pool != nil {
+		fmt.Fprintf(&b, "connection pool size: %d\n", len(c.pool.slots))
+	}
+	fmt.Fprintf(&b, "extra dial options: %d", len(c.dialOptions))
+	return b.String()
+}
+
+func caDescription(caFile string) string {
+	if caFile == "" {
+		return "system roots"
+	}
+	return caFile
+}
+
+func (c *Client) address() string {
+	if c.isUnixSocket() {
+		return c.Host
+	}
+	if c.resolverScheme != "" {
+		return fmt.Sprintf("%s:///%s", c.resolverScheme, c.hostPort())
+	}
+	return c.hostPort()
+}
+
+// hostPort joins Host and Port, bracketing IPv6 literals such as ::1.
+func (c *Client) hostPort() string {
+	if net.ParseIP(c.Host) != nil {
+		return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	}
+	return fmt.Sprintf("%s:%v", c.Host, c.Port)
+}
+
+func (c *Client) isUnixSocket() bool {
+	return strings.HasPrefix(c.Host, "unix:")
+}
+
+func (c *Client) validate() error {
+	if c.Host == "" {
+		return errors.New("host must not be empty")
+	}
+	if !c.isUnixSocket() && (c.Port <= 0 || c.Port > 65535) {
+		return fmt.Errorf("port %d is out of range", c.Port)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative, got %s", c.Timeout)
+	}
+	if c.TLSConfig != nil {
+		if err := c.TLSConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid TLS config: %w", err)
+		}
+	}
+	if c.strictCertValidation {
+		if err := c.checkClientCertValidity(time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkClientCertValidity returns an error if the client certificate is not
+// valid at now.
+func (c *Client) checkClientCertValidity(now time.Time) error {
+	if c.TLSConfig == nil {
+		return nil
+	}
+	tlsConfig, err := c.TLSConfig.GetTLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build TLS config: %w", err)
+	}
+
+	for _, cert := range tlsConfig.Certificates {
+		leaf := cert.Leaf
+		if leaf == nil {
+			leaf, err = x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse client certificate: %w", err)
+			}
+		}
+		if now.After(leaf.NotAfter) {
+			return fmt.Errorf("client certificate %q expired at %s", leaf.Subject.CommonName, leaf.NotAfter.Format(time.RFC3339))
+		}
+		if now.Before(leaf.NotBefore) {
+			return fmt.Errorf("client certificate %q is not valid until %s", leaf.Subject.CommonName, leaf.NotBefore.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout)
+	}
+	if c.propagateDeadline {
+		if _, ok := ctx.Deadline(); !ok {
+			return context.WithTimeout(ctx, defaultPropagatedDeadline)
+		}
+	}
+	return ctx, func() {}
+}
+
+// tlsConfig returns a copy of the client's TLS configuration, or nil when the
+// connection is insecure.
+func (c *Client) tlsConfig() (*tls.Config, error) {
+	if c.TLSConfig != nil {
+		tlsConfig, err := c.TLSConfig.GetTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS config: %w", err)
+		}
+		return tlsConfig, nil
+	}
+	return nil, nil
+}
+
+func (c *Client) dial(ctx context.Context, callOpts callOptions) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		if callOpts.serverName != "" {
+			tlsConfig.ServerName = callOpts.serverName
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	dialCtx := ctx
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, c.dialOptions...)
+	if c.dialTimeout > 0 {
+		// Block until the connection is up so that establishing it is
+		// bounded by the dial timeout rather than by the RPC's deadline.
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, c.dialTimeout)
+		defer cancel()
+		dialOptions = append(dialOptions, grpc.WithReturnConnectionError())
+	}
+	conn, err := grpc.DialContext(dialCtx, c.address(), dialOptions...)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to gRPC server (%s): %w", c.connectionDescription(tlsConfig, callOpts), err)
+		// grpc flattens the connection error into a string, so mark the
+		// failure as an outage unless the caller gave up first.
+		if ctx.Err() == nil {
+			err = fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connectionDescription describes the target and transport security of a
+// connection for error messages. It never includes key material.
+func (c *Client) connectionDescription(tlsConfig *tls.Config, callOpts callOptions) string {
+	if tlsConfig == nil {
+		return fmt.Sprintf("target %s, insecure", c.address())
+	}
+	serverName := tlsConfig.ServerName
+	if callOpts.serverName != "" {
+		serverName = callOpts.serverName
+	}
+	if serverName == "" {
+		return fmt.Sprintf("target %s, TLS, server name from target", c.address())
+	}
+	return fmt.Sprintf("target %s, TLS, server name %q", c.address(), serverName)
+}
+
+// logIfSlow logs a warning when an operation on key that began at start took
+// longer than the WithSlowLogThreshold threshold.
+func (c *Client) logIfSlow(op, key string, start time.Time) {
+	if c.slowThreshold <= 0 {
+		return
+	}
+	if elapsed := time.Since(start); elapsed > c.slowThreshold {
+		log.Printf("warning: slow parameter store %s of key %q took %s (threshold %s)", op, key, elapsed, c.slowThreshold)
+	}
+}
+
+// authenticate returns the context and request password to use for secret.
+// With WithSecretInMetadata the secret travels in a metadata header and the
+// request password is left empty.
+func (c *Client) authenticate(ctx context.Context, secret string) (context.Context, string) {
+	if c.secretHeader == "" {
+		return ctx, secret
+	}
+	return metadata.AppendToOutgoingContext(ctx, c.secretHeader, secret), ""
+}
+
+// conn returns a connection for one call and a function releasing it. Pooled
+// connections stay open; calls that override the TLS server name always get a
+// dedicated connection.
+func (c *Client) conn(ctx context.Context, callOpts callOptions) (*grpc.ClientConn, func(), error) {
+	if c.pool != nil && callOpts.serverName == "" {
+		conn, err := c.pool.get(ctx, func(ctx context.Context) (*grpc.ClientConn, error) {
+			return c.dial(ctx, callOpts)
+		})
+		return conn, func() {}, err
+	}
+
+	conn, err := c.dial(ctx, callOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, func() { conn.Close() }, nil
+}
+
+// Close stops the client from starting new calls, which then fail with
+// ErrClientClosed, waits for in-flight calls to finish and closes the
+// connections held by WithConnectionPool. If ctx ends before the calls have
+// drained, the connections are closed anyway and the context error is
+// returned.
+func (c *Client) Close(ctx context.Context) error {
+	c.closeMu.Lock()
+	c.closed = true
+	c.closeMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out draining in-flight calls: %w", ctx.Err())
+	}
+
+	if c.pool != nil {
+		err = errors.Join(err, c.pool.close())
+	}
+	return err
+}
+
+// begin registers an in-flight call, failing once Close has been called.
+func (c *Client) begin() error {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closed {
+		return ErrClientClosed
+	}
+	c.inflight.Add(1)
+	return nil
+}
+
+// waitForRateLimit waits for the WithRateLimit limiter, bounded by the
+// client's timeout like the RPC itself, so a wait that would exceed it fails
+// at once with ErrRateLimited.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrRateLimited, err)
+	}
+	return nil
+}
+
+// invoke waits for the rate limit, obtains a connection and runs rpc. The
+// rate-limit wait and the RPC are each bounded by the client's timeout; the
+// dial is bounded by WithDialTimeout instead.
+func (c *Client) invoke(ctx context.Context, rpc func(context.Context, pb.ParameterStoreClient) error, opts ...CallOption) (err error) {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.inflight.Done()
+
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	dialFailed := false
+	defer func() { c.breaker.record(dialFailed || isOutage(err)) }()
+
+	callOpts := newCallOptions(opts)
+	conn, release, err := c.conn(ctx, callOpts)
+	if err != nil {
+		dialFailed = true
+		return err
+	}
+	defer release()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if c.metadataFromContext != nil {
+		for k, v := range c.metadataFromContext(ctx) {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		}
+	}
+
+	err = normalizeGrpcError(rpc(ctx, pb.NewParameterStoreClient(conn)))
+	if errors.Is(err, ErrUnavailable) {
+		// Connections are established lazily, so TLS handshake failures
+		// surface here rather than in dial.
+		tlsConfig, _ := c.tlsConfig()
+		err = fmt.Errorf("%w (%s)", err, c.connectionDescription(tlsConfig, callOpts))
+	}
+	return err
+}
+
+func (c *Client) Retrieve(key, secret string) (string, error) {
+	return c.RetrieveContext(context.Background(), key, secret)
+}
+
+func (c *Client) RetrieveContext(ctx context.Context, key, secret string, opts ...CallOption) (string, error) {
+	if c.retrieveGroup == nil {
+		return c.retrieve(ctx, key, secret, opts...)
+	}
+	groupKey := strings.Join([]string{key, secret, newCallOptions(opts).serverName}, "\x00")
+	// The shared call must outlive any one caller, so it ignores the first
+	// caller's cancellation and is bounded only by the client's timeout.
+	// Each caller still stops waiting when its own context ends.
+	sharedCtx := context.WithoutCancel(ctx)
+	ch := c.retrieveGroup.DoChan(groupKey, func() (any, error) {
+		return c.retrieve(sharedCtx, key, secret, opts...)
+	})
+	select {
+	case res := <-ch:
+		return res.Val.(string), res.Err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (c *Client) retrieve(ctx context.Context, key, secret string, opts ...CallOption) (string, error) {
+	value, _, err := c.retrieveWithMetadata(ctx, key, secret, opts...)
+	return value, err
+}
+
+func (c *Client) retrieveWithMetadata(ctx context.Context, key, secret string, opts ...CallOption) (string, ValueMetadata, error) {
+	value, meta, err := c.fetch(ctx, key, secret, opts...)
+	if err != nil || !c.interpolation {
+		return value, meta, err
+	}
+	value, err = c.interpolate(ctx, secret, value, []string{key})
+	if err != nil {
+		return "", ValueMetadata{}, err
+	}
+	return value, meta, nil
+}
+
+// fetch retrieves and decodes the value of key without interpolating it.
+func (c *Client) fetch(ctx context.Context, key, secret string, opts ...CallOption) (_ string, _ ValueMetadata, err error) {
+	start := time.Now()
+	defer func() { c.audit("retrieve", key, start, err) }()
+	defer c.logIfSlow("retrieve", key, start)
+
+	var retrieveResp *pb.RetrieveResponse
+	err = c.backoff.retry(ctx, isOutage, func() error {
+		return c.invoke(ctx, func(ctx context.Context, client pb.ParameterStoreClient) error {
+			ctx, password := c.authenticate(ctx, secret)
+			var err error
+			retrieveResp, err = client.Retrieve(ctx, &pb.RetrieveRequest{
+				Key:      c.keyPrefix + key,
+				Password: password,
+			}, newCallOptions(opts).grpcCallOptions...)
+			return err
+		}, opts...)
+	})
+	if err != nil {
+		return "", ValueMetadata{}, err
+	}
+
+	value, err := c.decodeValue(ctx, key, retrieveResp.GetValue())
+	if err != nil {
+		return "", ValueMetadata{}, err
+	}
+
+	meta := ValueMetadata{Version: retrieveResp.GetVersion()}
+	if updatedAt := retrieveResp.GetUpdatedAtUnix(); updatedAt != 0 {
+		meta.UpdatedAt = time.Unix(updatedAt, 0)
+	}
+	return value, meta, nil
+}
+
+// decodeValue turns a value as stored on the server into the value returned
+// to the caller, decrypting it with the KeyProvider and then applying the
+// value transformer.
+func (c *Client) decodeValue(ctx context.Context, key, value string) (string, error) {
+	if c.keyProvider != nil {
+		ciphertext, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode encrypted value of key %q: %w", key, err)
+		}
+		plaintext, err := c.keyProvider.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt value of key %q: %w", key, err)
+		}
+		value = string(plaintext)
+	}
+	if c.valueTransformer != nil {
+		var err error
+		value, err = c.valueTransformer(key, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to transform value of key %q: %w", key, err)
+		}
+	}
+	return value, nil
+}
+
+// encodeValue is the inverse of decodeValue: it applies the value encoder and
+// then encrypts the result with the KeyProvider.
+func (c *Client) encodeValue(ctx context.Context, key, value string) (string, error) {
+	if c.valueEncoder != nil {
+		var err error
+		value, err = c.valueEncoder(key, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode value of key %q: %w", key, err)
+		}
+	}
+	if c.keyProvider != nil {
+		ciphertext, err := c.keyProvider.Encrypt(ctx, []byte(value))
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt value of key %q: %w", key, err)
+		}
+		value = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return value, nil
+}
+
+// ValueMetadata describes a stored value. Servers that do not track metadata
+// leave both fields zero.
+type ValueMetadata struct {
+	Version   string
+	UpdatedAt time.Time
+}
+
+// RetrieveWithMetadata is like Retrieve but also returns the value's version
+// and last update time as reported by the server.
+func (c *Client) RetrieveWithMetadata(key, secret string) (value string, meta ValueMetadata, err error) {
+	return c.retrieveWithMetadata(context.Background(), key, secret)
+}
+
+// RetrieveWithTimestamp is like Retrieve but also returns the local time at
+// which the value was received, for callers making their own caching
+// decisions.
+func (c *Client) RetrieveWithTimestamp(key, secret string) (value string, fetchedAt time.Time, err error) {
+	value, err = c.Retrieve(key, secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return value, time.Now(), nil
+}
+
+// RetrieveIfChanged retrieves key and reports whether its version differs
+// from knownVersion. When it does not, value is empty and changed is false.
+// A server that does not report versions always yields changed true. The
+// comparison is made by the client, so the value is still transferred.
+func (c *Client) RetrieveIfChanged(key, secret, knownVersion string) (value string, changed bool, newVersion string, err error) {
+	value, meta, err := c.retrieveWithMetadata(context.Background(), key, secret)
+	if err != nil {
+		return "", false, "", err
+	}
+	if meta.Version != "" && meta.Version == knownVersion {
+		return "", false, meta.Version, nil
+	}
+	return value, true, meta.Version, nil
+}
+
+// RetrieveWithTrailer is like RetrieveContext but also returns the trailer
+// metadata sent by the server, such as a value version header.
+func (c *Client) RetrieveWithTrailer(ctx context.Context, key, secret string) (string, metadata.MD, error) {
+	var trailer metadata.MD
+	value, err := c.retrieve(ctx, key, secret, withGrpcCallOptions(grpc.Trailer(&trailer)))
+	if err != nil {
+		return "", trailer, err
+	}
+	return value, trailer, nil
+}
+
+// RetrieveWithSecrets tries each secret in order until one is accepted,
+// which helps while a secret is being rotated. Only ErrUnauthenticated moves
+// on to the next secret; ErrNotFound and other errors are returned at once.
+func (c *Client) RetrieveWithSecrets(key string, secrets ...string) (string, error) {
+	if len(secrets) == 0 {
+		return "", errors.New("at least one secret is required")
+	}
+	var err error
+	for _, secret := range secrets {
+		var value string
+		value, err = c.Retrieve(key, secret)
+		if !errors.Is(err, ErrUnauthenticated) {
+			return value, err
+		}
+	}
+	return "", err
+}
+
+// RetrieveBatchWithSecrets retrieves every key in items, which maps each key
+// to the secret it requires, over a single connection. The values that could
+// be retrieved are returned together with the per-key failures joined into
+// one error. The client's Timeout bounds the whole batch.
+func (c *Client) RetrieveBatchWithSecrets(items map[string]string) (map[string]string, error) {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make(map[string]string, len(items))
+	var errs []error
+	err := c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+		for _, key := range keys {
+			start := time.Now()
+			keyCtx, password := c.authenticate(ctx, items[key])
+			retrieveResp, err := client.Retrieve(keyCtx, &pb.RetrieveRequest{
+				Key:      c.keyPrefix + key,
+				Password: password,
+			})
+			err = normalizeGrpcError(err)
+			var value string
+			if err == nil {
+				value, err = c.decodeValue(ctx, key, retrieveResp.GetValue())
+			}
+			c.audit("retrieve", key, start, err)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("retrieve %q: %w", key, err))
+				continue
+			}
+			values[key] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.interpolation {
+		for _, key := range keys {
+			value, ok := values[key]
+			if !ok {
+				continue
+			}
+			value, err := c.interpolate(context.Background(), items[key], value, []string{key})
+			if err != nil {
+				delete(values, key)
+				errs = append(errs, fmt.Errorf("retrieve %q: %w", key, err))
+				continue
+			}
+			values[key] = value
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// RetrieveNonEmpty is like Retrieve but returns ErrEmptyValue when the key
+// exists and holds an empty string. A missing key still fails with
+// ErrNotFound.
+func (c *Client) RetrieveNonEmpty(key, secret string) (string, error) {
+	value, err := c.Retrieve(key, secret)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("key %q: %w", key, ErrEmptyValue)
+	}
+	return value, nil
+}
+
+// RetrieveSecret is like Retrieve but wraps the value in a SecretString so it
+// cannot be logged by accident.
+func (c *Client) RetrieveSecret(key, secret string) (SecretString, error) {
+	value, err := c.Retrieve(key, secret)
+	if err != nil {
+		return SecretString{}, err
+	}
+	return NewSecretString(value), nil
+}
+
+func (c *Client) Store(key, secret, value string) error {
+	return c.StoreContext(context.Background(), key, secret, value)
+}
+
+func (c *Client) StoreContext(ctx context.Context, key, secret, value string, opts ...CallOption) error {
+	_, err := c.store(ctx, key, secret, value, opts...)
+	return err
+}
+
+// StoreWithResponse stores value under key and returns the confirmation
+// message sent back by the server.
+func (c *Client) StoreWithResponse(key, secret, value string) (string, error) {
+	return c.store(context.Background(), key, secret, value)
+}
+
+// StoreVerified stores value, reads it back and returns ErrVerificationFailed
+// if the server did not return exactly the same bytes, catching silent
+// truncation or re-encoding. The comparison is constant-time. The read-back
+// skips WithInterpolation, so values holding ${NAME} references verify as
+// stored.
+func (c *Client) StoreVerified(key, secret, value string) error {
+	if err := c.Store(key, secret, value); err != nil {
+		return err
+	}
+	stored, _, err := c.fetch(context.Background(), key, secret)
+	if err != nil {
+		return fmt.Errorf("failed to read back key %q: %w", key, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(value)) != 1 {
+		return fmt.Errorf("key %q: %w", key, ErrVerificationFailed)
+	}
+	return nil
+}
+
+// SetSecret sets the secret used by RetrieveKey and StoreKey. It is safe to
+// call while other calls are in flight, e.g. from a SIGHUP handler rotating
+// the secret; methods taking an explicit secret are unaffected.
+func (c *Client) SetSecret(secret string) {
+	c.secretMu.Lock()
+	defer c.secretMu.Unlock()
+	c.secret = secret
+}
+
+func (c *Client) currentSecret() string {
+	c.secretMu.RLock()
+	defer c.secretMu.RUnlock()
+	return c.secret
+}
+
+// RetrieveKey retrieves key using the secret set with SetSecret.
+func (c *Client) RetrieveKey(key string) (string, error) {
+	return c.Retrieve(key, c.currentSecret())
+}
+
+// StoreKey stores value under key using the secret set with SetSecret.
+func (c *Client) StoreKey(key, value string) error {
+	return c.Store(key, c.currentSecret(), value)
+}
+
+func (c *Client) store(ctx context.Context, key, secret, value string, opts ...CallOption) (_ string, err error) {
+	start := time.Now()
+	defer func() { c.audit("store", key, start, err) }()
+	if c.readOnly {
+		return "", ErrReadOnly
+	}
+	defer c.logIfSlow("store", key, start)
+
+	value, err = c.encodeValue(ctx, key, value)
+	if err != nil {
+		return "", err
+	}
+
+	var message string
+	err = c.backoff.retry(ctx, isOutage, func() error {
+		return c.invoke(ctx, func(ctx context.Context, client pb.ParameterStoreClient) error {
+			ctx, password := c.authenticate(ctx, secret)
+			storeResp, err := client.Store(ctx, &pb.StoreRequest{
+				Key:      c.keyPrefix + key,
+				Value:    value,
+				Password: password,
+			})
+			message = storeResp.GetMessage()
+			return err
+		}, opts...)
+	})
+	if err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// AddAccess grants secret access to key, authorized by masterPassword. Both
+// are sent in the request itself, even with WithSecretInMetadata, since secret is
+// the credential being granted rather than the caller's own.
+func (c *Client) AddAccess(key, secret, masterPassword string) (err error) {
+	start := time.Now()
+	defer func() { c.audit("add_access", key, start, err) }()
+	if c.readOnly {
+		return ErrReadOnly
+	}
+
+	return c.backoff.retry(context.Background(), isOutage, func() error {
+		return c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+			_, err := client.AddAccess(ctx, &pb.AddAccessRequest{
+				Key:            c.keyPrefix + key,
+				Password:       secret,
+				MasterPassword: masterPassword,
+			})
+			return err
+		})
+	})
+}
+
+// List returns the keys stored under prefix. An empty prefix lists every key
+// the secret has access to. With WithKeyPrefix the client's prefix is
+// prepended to prefix and stripped from the returned keys, so they can be
+// passed straight back to Retrieve.
+func (c *Client) List(prefix, secret string) (_ []string, err error) {
+	start := time.Now()
+	defer func() { c.audit("list", prefix, start, err) }()
+
+	var keys []string
+	err = c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+		ctx, password := c.authenticate(ctx, secret)
+		listResp, err := client.List(ctx, &pb.ListRequest{
+			Prefix:   c.keyPrefix + prefix,
+			Password: password,
+		})
+		keys = listResp.GetKeys()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, c.keyPrefix)
+	}
+	return keys, nil
+}
+
+// StoreTransaction stores all items in a single StoreBatch RPC, which the
+// server applies atomically: if any item is rejected, none are stored.
+func (c *Client) StoreTransaction(items map[string]string, secret string) (err error) {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	start := time.Now()
+	defer func() {
+		for _, key := range keys {
+			c.audit("store_transaction", key, start, err)
+		}
+	}()
+	if c.readOnly {
+		return ErrReadOnly
+	}
+
+	pairs := make([]*pb.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		value, err := c.encodeValue(context.Background(), key, items[key])
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, &pb.KeyValue{Key: c.keyPrefix + key, Value: value})
+	}
+
+	return c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+		ctx, password := c.authenticate(ctx, secret)
+		_, err := client.StoreBatch(ctx, &pb.StoreBatchRequest{
+			Items:    pairs,
+			Password: password,
+		})
+		return err
+	})
+}
+
+// StoreIfAbsent stores value under key only when the key does not exist yet,
+// and reports whether it created the value. The existence check is a raw
+// Retrieve that never decodes or interpolates the existing value, so only
+// the server's answer matters: NotFound is treated as absent and any other
+// error is returned as-is.
+//
+// The existence check and the store are two separate RPCs, so another writer
+// can still create the key in between (a time-of-check/time-of-use race). A
+// conditional store performed by the server would be the stronger guarantee.
+func (c *Client) StoreIfAbsent(key, secret, value string) (created bool, err error) {
+	if c.readOnly {
+		return false, ErrReadOnly
+	}
+	ctx := context.Background()
+	err = c.backoff.retry(ctx, isOutage, func() error {
+		return c.invoke(ctx, func(ctx context.Context, client pb.ParameterStoreClient) error {
+			ctx, password := c.authenticate(ctx, secret)
+			_, err := client.Retrieve(ctx, &pb.RetrieveRequest{
+				Key:      c.keyPrefix + key,
+				Password: password,
+			})
+			return err
+		})
+	})
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, err
+	}
+	if err := c.Store(key, secret, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func GrpcimpleRetrieve(ServerAddress string, AuthenticationPassword string, key string) (val string, err error) {
 	// Dial to the server
 	conn, err := grpc.Dial(ServerAddress, grpc.WithInsecure())
@@ -0,0 +1,960 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+func TestStoreAndRetrieve(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	if err := c.Store("db-password", testSecret, "hunter2"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	value, err := c.Retrieve("db-password", testSecret)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("Retrieve = %q, want %q", value, "hunter2")
+	}
+
+	if _, err := c.Retrieve("missing", testSecret); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Retrieve of missing key = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Retrieve("db-password", "wrong"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Retrieve with wrong secret = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestStoreIfAbsent(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	created, err := c.StoreIfAbsent("key", testSecret, "first")
+	if err != nil || !created {
+		t.Fatalf("StoreIfAbsent on absent key = %v, %v; want true, nil", created, err)
+	}
+	created, err = c.StoreIfAbsent("key", testSecret, "second")
+	if err != nil || created {
+		t.Fatalf("StoreIfAbsent on present key = %v, %v; want false, nil", created, err)
+	}
+	if value, _ := s.get("key"); value != "first" {
+		t.Errorf("stored value = %q, want %q", value, "first")
+	}
+
+	if _, err := c.StoreIfAbsent("other", "wrong", "value"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("StoreIfAbsent with wrong secret = %v, want ErrUnauthenticated", err)
+	}
+}
+
+func TestStoreIfAbsentIgnoresUnreadableValues(t *testing.T) {
+	s := startMockServer(t)
+	s.set("dangling", "${nowhere}")
+	s.set("sealed", "ciphertext")
+	c := s.newClient(t, WithInterpolation(), WithValueTransformer(func(key, raw string) (string, error) {
+		if key == "sealed" {
+			return "", errors.New("cannot decrypt")
+		}
+		return raw, nil
+	}))
+
+	for _, key := range []string{"dangling", "sealed"} {
+		if _, err := c.Retrieve(key, testSecret); err == nil {
+			t.Fatalf("Retrieve(%q) succeeded, want a decode or interpolation error", key)
+		}
+		created, err := c.StoreIfAbsent(key, testSecret, "new")
+		if err != nil || created {
+			t.Errorf("StoreIfAbsent(%q) = %v, %v; want false, nil", key, created, err)
+		}
+	}
+	if n := s.stores.Load(); n != 0 {
+		t.Errorf("server saw %d stores, want 0", n)
+	}
+}
+
+func TestStoreWithResponse(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	message, err := c.StoreWithResponse("key", testSecret, "value")
+	if err != nil {
+		t.Fatalf("StoreWithResponse: %v", err)
+	}
+	if message != "stored key" {
+		t.Errorf("message = %q, want %q", message, "stored key")
+	}
+}
+
+func TestSetSecretWhileCallsInFlight(t *testing.T) {
+	s := startMockServer(t)
+	s.secrets = []string{testSecret, "rotated"}
+	s.set("key", "value")
+	c := s.newClient(t)
+	c.SetSecret(testSecret)
+	if err := c.StoreKey("key", "value"); err != nil {
+		t.Fatalf("StoreKey: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := c.RetrieveKey("key"); err != nil {
+					t.Errorf("RetrieveKey: %v", err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			c.SetSecret("rotated")
+		} else {
+			c.SetSecret(testSecret)
+		}
+	}
+	wg.Wait()
+}
+
+func TestSingleFlightCoalescesRetrieves(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	release := make(chan struct{})
+	s.beforeRetrieve = func(context.Context, *pb.RetrieveRequest) error {
+		<-release
+		return nil
+	}
+	c := s.newClient(t, WithSingleFlight())
+
+	const callers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.Retrieve("key", testSecret)
+			if err != nil || value != "value" {
+				t.Errorf("Retrieve = %q, %v", value, err)
+			}
+		}()
+	}
+	waitFor(t, func() bool { return s.retrieves.Load() == 1 })
+	// Give the remaining callers time to join the call in flight.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := s.retrieves.Load(); n != 1 {
+		t.Errorf("server saw %d retrieves, want 1", n)
+	}
+}
+
+func TestSingleFlightCallerCancellation(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	release := make(chan struct{})
+	s.beforeRetrieve = func(context.Context, *pb.RetrieveRequest) error {
+		<-release
+		return nil
+	}
+	c := s.newClient(t, WithSingleFlight())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.RetrieveContext(ctx, "key", testSecret)
+		firstErr <- err
+	}()
+	waitFor(t, func() bool { return s.retrieves.Load() == 1 })
+
+	second := make(chan string, 1)
+	go func() {
+		value, err := c.Retrieve("key", testSecret)
+		if err != nil {
+			t.Errorf("second Retrieve: %v", err)
+		}
+		second <- value
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Retrieve = %v, want context.Canceled", err)
+	}
+	close(release)
+	if value := <-second; value != "value" {
+		t.Errorf("second Retrieve = %q, want %q", value, "value")
+	}
+}
+
+func TestStoreTransactionRollsBack(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	err := c.StoreTransaction(map[string]string{"a": "1", "b": "2", "": "invalid"}, testSecret)
+	if err == nil {
+		t.Fatal("StoreTransaction with an invalid item succeeded")
+	}
+	for _, key := range []string{"a", "b"} {
+		if _, ok := s.get(key); ok {
+			t.Errorf("key %q was stored despite the rollback", key)
+		}
+	}
+
+	if err := c.StoreTransaction(map[string]string{"a": "1", "b": "2"}, testSecret); err != nil {
+		t.Fatalf("StoreTransaction: %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if value, _ := s.get(key); value != want {
+			t.Errorf("key %q = %q, want %q", key, value, want)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithReadOnly())
+
+	mutations := map[string]func() error{
+		"Store": func() error { return c.Store("key", testSecret, "new") },
+		"StoreTransaction": func() error {
+			return c.StoreTransaction(map[string]string{"key": "new"}, testSecret)
+		},
+		"StoreIfAbsent": func() error {
+			_, err := c.StoreIfAbsent("other", testSecret, "new")
+			return err
+		},
+		"StoreLarge":  func() error { return c.StoreLarge("key", testSecret, strings.NewReader("new")) },
+		"StoreDryRun": func() error { return c.StoreDryRun("key", testSecret, "new") },
+		"AddAccess":   func() error { return c.AddAccess("key", "reader", testMasterPassword) },
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if n := s.stores.Load(); n != 0 {
+		t.Errorf("server saw %d stores, want 0", n)
+	}
+	if grants := s.grants["key"]; len(grants) != 0 {
+		t.Errorf("server granted access to %q, want no grants", grants)
+	}
+
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Errorf("Retrieve = %q, %v; want %q", value, err, "value")
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t, WithKeyPrefix("svc/"))
+
+	if err := c.Store("db", testSecret, "value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if _, ok := s.get("svc/db"); !ok {
+		t.Error("value not stored under svc/db")
+	}
+	if value, err := c.Retrieve("db", testSecret); err != nil || value != "value" {
+		t.Errorf("Retrieve = %q, %v", value, err)
+	}
+	if err := c.AddAccess("db", "reader", testMasterPassword); err != nil {
+		t.Fatalf("AddAccess: %v", err)
+	}
+	if grants := s.grants["svc/db"]; len(grants) != 1 || grants[0] != "reader" {
+		t.Errorf("grants for svc/db = %q, want [reader]", grants)
+	}
+
+	s.set("other/x", "ignored")
+	keys, err := c.List("", testSecret)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "db" {
+		t.Errorf("List = %q, want [db]", keys)
+	}
+}
+
+func TestAddAccess(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+
+	if _, err := c.Retrieve("key", "reader"); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("Retrieve before AddAccess = %v, want ErrUnauthenticated", err)
+	}
+	if err := c.AddAccess("key", "reader", "wrong"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("AddAccess with the wrong master password = %v, want ErrUnauthenticated", err)
+	}
+	if err := c.AddAccess("missing", "reader", testMasterPassword); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddAccess of a missing key = %v, want ErrNotFound", err)
+	}
+	if err := c.AddAccess("key", "reader", testMasterPassword); err != nil {
+		t.Fatalf("AddAccess: %v", err)
+	}
+	if value, err := c.Retrieve("key", "reader"); err != nil || value != "value" {
+		t.Errorf("Retrieve after AddAccess = %q, %v; want %q", value, err, "value")
+	}
+}
+
+func TestList(t *testing.T) {
+	s := startMockServer(t)
+	s.set("app/a", "1")
+	s.set("app/b", "2")
+	s.set("other", "3")
+	c := s.newClient(t)
+
+	keys, err := c.List("app/", testSecret)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if strings.Join(keys, ",") != "app/a,app/b" {
+		t.Errorf("List = %q, want [app/a app/b]", keys)
+	}
+
+	keys, err = c.List("none/", testSecret)
+	if err != nil || len(keys) != 0 {
+		t.Errorf("List of empty prefix = %q, %v; want no keys", keys, err)
+	}
+}
+
+func TestValueTransformerAndEncoder(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t,
+		WithValueEncoder(func(_, value string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(value)), nil
+		}),
+		WithValueTransformer(func(_, raw string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(raw)
+			return string(decoded), err
+		}),
+	)
+
+	if err := c.Store("key", testSecret, "plain"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if raw, _ := s.get("key"); raw != base64.StdEncoding.EncodeToString([]byte("plain")) {
+		t.Errorf("stored value = %q, want it base64-encoded", raw)
+	}
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "plain" {
+		t.Errorf("Retrieve = %q, %v; want %q", value, err, "plain")
+	}
+
+	s.set("bad", "not base64!")
+	if _, err := c.Retrieve("bad", testSecret); err == nil {
+		t.Error("Retrieve succeeded although the transformer failed")
+	}
+}
+
+func TestRetrieveNonEmpty(t *testing.T) {
+	s := startMockServer(t)
+	s.set("empty", "")
+	s.set("full", "value")
+	c := s.newClient(t)
+
+	if _, err := c.RetrieveNonEmpty("empty", testSecret); !errors.Is(err, ErrEmptyValue) {
+		t.Errorf("RetrieveNonEmpty of empty value = %v, want ErrEmptyValue", err)
+	}
+	if value, err := c.RetrieveNonEmpty("full", testSecret); err != nil || value != "value" {
+		t.Errorf("RetrieveNonEmpty = %q, %v", value, err)
+	}
+	if _, err := c.RetrieveNonEmpty("missing", testSecret); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RetrieveNonEmpty of missing key = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRetrieveSecretRedacts(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "hunter2")
+	c := s.newClient(t)
+
+	secret, err := c.RetrieveSecret("key", testSecret)
+	if err != nil {
+		t.Fatalf("RetrieveSecret: %v", err)
+	}
+	for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+		if out := fmt.Sprintf(format, secret); strings.Contains(out, "hunter2") {
+			t.Errorf("%s formatting revealed the secret: %s", format, out)
+		}
+	}
+	if secret.Reveal() != "hunter2" {
+		t.Errorf("Reveal = %q, want %q", secret.Reveal(), "hunter2")
+	}
+}
+
+func TestRetrieveWithMetadata(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "v1")
+	c := s.newClient(t)
+
+	value, meta, err := c.RetrieveWithMetadata("key", testSecret)
+	if err != nil {
+		t.Fatalf("RetrieveWithMetadata: %v", err)
+	}
+	if value != "v1" || meta.Version != "1" || meta.UpdatedAt.Unix() != 1700000000 {
+		t.Errorf("RetrieveWithMetadata = %q, %+v", value, meta)
+	}
+}
+
+func TestRetrieveIfChanged(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "v1")
+	c := s.newClient(t)
+
+	value, changed, version, err := c.RetrieveIfChanged("key", testSecret, "")
+	if err != nil || !changed || value != "v1" {
+		t.Fatalf("first RetrieveIfChanged = %q, %v, %v", value, changed, err)
+	}
+	value, changed, _, err = c.RetrieveIfChanged("key", testSecret, version)
+	if err != nil || changed || value != "" {
+		t.Errorf("unchanged RetrieveIfChanged = %q, %v, %v; want no value", value, changed, err)
+	}
+
+	s.set("key", "v2")
+	value, changed, _, err = c.RetrieveIfChanged("key", testSecret, version)
+	if err != nil || !changed || value != "v2" {
+		t.Errorf("changed RetrieveIfChanged = %q, %v, %v; want v2", value, changed, err)
+	}
+}
+
+func TestRetrieveWithTimestamp(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+
+	_, fetchedAt, err := c.RetrieveWithTimestamp("key", testSecret)
+	if err != nil {
+		t.Fatalf("RetrieveWithTimestamp: %v", err)
+	}
+	if d := time.Since(fetchedAt); d < 0 || d > 5*time.Second {
+		t.Errorf("fetchedAt is %s away from now", d)
+	}
+}
+
+func TestRetrieveWithTrailer(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+
+	value, trailer, err := c.RetrieveWithTrailer(context.Background(), "key", testSecret)
+	if err != nil {
+		t.Fatalf("RetrieveWithTrailer: %v", err)
+	}
+	if value != "value" {
+		t.Errorf("value = %q, want %q", value, "value")
+	}
+	if got := trailer.Get("x-value-version"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("trailer x-value-version = %q, want [1]", got)
+	}
+}
+
+func TestRetrieveWithSecrets(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+
+	value, err := c.RetrieveWithSecrets("key", "old", testSecret)
+	if err != nil || value != "value" {
+		t.Errorf("RetrieveWithSecrets = %q, %v", value, err)
+	}
+	if _, err := c.RetrieveWithSecrets("key", "old", "older"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("RetrieveWithSecrets with no valid secret = %v, want ErrUnauthenticated", err)
+	}
+	if _, err := c.RetrieveWithSecrets("missing", testSecret, "other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RetrieveWithSecrets of missing key = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRetrieveBatchWithSecrets(t *testing.T) {
+	s := startMockServer(t)
+	s.keySecrets = map[string]string{"a": "secret-a", "b": "secret-b"}
+	s.set("a", "1")
+	s.set("b", "2")
+	c := s.newClient(t)
+
+	values, err := c.RetrieveBatchWithSecrets(map[string]string{"a": "secret-a", "b": "secret-b"})
+	if err != nil {
+		t.Fatalf("RetrieveBatchWithSecrets: %v", err)
+	}
+	if values["a"] != "1" || values["b"] != "2" {
+		t.Errorf("values = %v", values)
+	}
+
+	values, err = c.RetrieveBatchWithSecrets(map[string]string{"a": "secret-a", "b": "secret-a"})
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("err = %v, want ErrUnauthenticated for b", err)
+	}
+	if values["a"] != "1" || len(values) != 1 {
+		t.Errorf("values = %v, want only a", values)
+	}
+}
+
+func TestStoreVerified(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+	if err := c.StoreVerified("key", testSecret, "value"); err != nil {
+		t.Errorf("StoreVerified: %v", err)
+	}
+
+	s.mangle = func(value string) string { return value[:len(value)-1] }
+	if err := c.StoreVerified("key", testSecret, "value"); !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("StoreVerified against a mangling server = %v, want ErrVerificationFailed", err)
+	}
+}
+
+func TestSecretInMetadata(t *testing.T) {
+	s := startMockServer(t)
+	s.secretHeader = "authorization"
+	c := s.newClient(t, WithSecretInMetadata("Authorization"))
+
+	if err := c.Store("key", testSecret, "value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Errorf("Retrieve = %q, %v", value, err)
+	}
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	type tenantKey struct{}
+	s := startMockServer(t)
+	s.set("key", "value")
+	var tenant string
+	s.beforeRetrieve = func(ctx context.Context, _ *pb.RetrieveRequest) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		tenant = strings.Join(md.Get("x-tenant-id"), ",")
+		return nil
+	}
+	c := s.newClient(t, WithMetadataFromContext(func(ctx context.Context) map[string]string {
+		id, _ := ctx.Value(tenantKey{}).(string)
+		return map[string]string{"x-tenant-id": id}
+	}))
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	if _, err := c.RetrieveContext(ctx, "key", testSecret); err != nil {
+		t.Fatalf("RetrieveContext: %v", err)
+	}
+	if tenant != "acme" {
+		t.Errorf("x-tenant-id = %q, want %q", tenant, "acme")
+	}
+}
+
+func TestAuthority(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	var authority string
+	s.beforeRetrieve = func(ctx context.Context, _ *pb.RetrieveRequest) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		authority = strings.Join(md.Get(":authority"), ",")
+		return nil
+	}
+	c := s.newClient(t, WithAuthority("store.internal"))
+
+	if _, err := c.Retrieve("key", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if authority != "store.internal" {
+		t.Errorf(":authority = %q, want %q", authority, "store.internal")
+	}
+}
+
+type stubCredentials struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *stubCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return map[string]string{"x-token": "token"}, nil
+}
+
+func (s *stubCredentials) RequireTransportSecurity() bool { return false }
+
+func TestPerRPCCredentials(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	var token string
+	s.beforeRetrieve = func(ctx context.Context, _ *pb.RetrieveRequest) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		token = strings.Join(md.Get("x-token"), ",")
+		return nil
+	}
+	creds := &stubCredentials{}
+	c := s.newClient(t, WithPerRPCCredentials(creds))
+
+	if _, err := c.Retrieve("key", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if creds.calls == 0 || token != "token" {
+		t.Errorf("credentials called %d times, server saw token %q", creds.calls, token)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	builder := manual.NewBuilderWithScheme("test")
+	builder.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: "bufnet"}}})
+	built := make(chan resolver.Target, 1)
+	builder.BuildCallback = func(target resolver.Target, _ resolver.ClientConn, _ resolver.BuildOptions) {
+		built <- target
+	}
+	c := s.newClient(t, WithResolver(builder))
+
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Fatalf("Retrieve = %q, %v", value, err)
+	}
+	select {
+	case target := <-built:
+		if target.URL.Scheme != "test" {
+			t.Errorf("resolver built for scheme %q, want test", target.URL.Scheme)
+		}
+	default:
+		t.Error("resolver was not consulted")
+	}
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	var hasDeadline bool
+	s.beforeRetrieve = func(ctx context.Context, _ *pb.RetrieveRequest) error {
+		_, hasDeadline = ctx.Deadline()
+		return nil
+	}
+
+	c := s.newClient(t)
+	c.Timeout = 0
+	if _, err := c.Retrieve("key", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if hasDeadline {
+		t.Error("server saw a deadline although the client sets none")
+	}
+
+	c = s.newClient(t, WithDeadlinePropagation(true))
+	c.Timeout = 0
+	if _, err := c.Retrieve("key", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if !hasDeadline {
+		t.Error("server saw no deadline with WithDeadlinePropagation")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithRateLimit(20, 1))
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := c.Retrieve("key", testSecret); err != nil {
+			t.Fatalf("Retrieve: %v", err)
+		}
+	}
+	// The first call uses the burst; the other four wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("5 calls at 20/s took %s, want at least 200ms", elapsed)
+	}
+}
+
+func TestRateLimitBoundedByTimeout(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithRateLimit(0.1, 1))
+	c.Timeout = 100 * time.Millisecond
+
+	if _, err := c.Retrieve("key", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	start := time.Now()
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Retrieve past the rate limit = %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("rate-limited Retrieve took %s, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	slowDial := func(ctx context.Context, addr string) (net.Conn, error) {
+		select {
+		case <-time.After(2 * time.Second):
+			return s.dial(ctx, addr)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c, err := NewClient("bufnet", 1, WithContextDialer(slowDial), WithDialTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.Timeout = 5 * time.Second
+
+	start := time.Now()
+	if _, err := c.Retrieve("key", testSecret); err == nil {
+		t.Fatal("Retrieve succeeded despite the slow dial")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Retrieve took %s, want the dial timeout to trip first", elapsed)
+	}
+}
+
+func TestCloseDrainsInFlightCalls(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	release := make(chan struct{})
+	s.beforeRetrieve = func(context.Context, *pb.RetrieveRequest) error {
+		<-release
+		return nil
+	}
+	c := s.newClient(t)
+
+	const calls = 5
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Retrieve("key", testSecret); err != nil {
+				t.Errorf("in-flight Retrieve: %v", err)
+			}
+		}()
+	}
+	waitFor(t, func() bool { return s.retrieves.Load() == calls })
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close(context.Background()) }()
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v before in-flight calls finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Retrieve after Close = %v, want ErrClientClosed", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if err := <-closed; err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestCloseTimesOut(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	release := make(chan struct{})
+	defer close(release)
+	s.beforeRetrieve = func(context.Context, *pb.RetrieveRequest) error {
+		<-release
+		return nil
+	}
+	c := s.newClient(t)
+	go c.Retrieve("key", testSecret)
+	waitFor(t, func() bool { return s.retrieves.Load() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	s := newMockServer()
+	s.set("key", "value")
+	path := t.TempDir() + "/ps.sock"
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serve(t, s, lis)
+
+	c, err := NewClient("unix://"+path, 0)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.Timeout = 5 * time.Second
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Errorf("Retrieve over unix socket = %q, %v", value, err)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"10.0.0.1", "10.0.0.1:8443"},
+		{"::1", "[::1]:8443"},
+		{"2001:db8::1", "[2001:db8::1]:8443"},
+		{"store.example.com", "store.example.com:8443"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(tt.host, 8443)
+		if err != nil {
+			t.Fatalf("NewClient(%q): %v", tt.host, err)
+		}
+		if got := c.address(); got != tt.want {
+			t.Errorf("address for host %q = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientValidation(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		opts []ClientOption
+	}{
+		{"", 8443, nil},
+		{"localhost", 0, nil},
+		{"localhost", 70000, nil},
+		{"localhost", 8443, []ClientOption{WithTLS(nil)}},
+		{"localhost", 8443, []ClientOption{WithRateLimit(0, 1)}},
+		{"localhost", 8443, []ClientOption{WithConnectionPool(0)}},
+	}
+	for _, tt := range tests {
+		if _, err := NewClient(tt.host, tt.port, tt.opts...); err == nil {
+			t.Errorf("NewClient(%q, %d) with %d options succeeded, want an error", tt.host, tt.port, len(tt.opts))
+		}
+	}
+}
+
+func TestClientStringRedactsSecret(t *testing.T) {
+	c, err := NewClient("localhost", 8443)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.SetSecret("hunter2")
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		if out := fmt.Sprintf(format, c); strings.Contains(out, "hunter2") {
+			t.Errorf("%s formatting revealed the secret: %s", format, out)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	c, err := NewClient("localhost", 8443, WithTLS(clientTLS), WithKeyPrefix("svc/"), WithDialTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.SetSecret("hunter2")
+
+	description := c.Describe()
+	for _, want := range []string{"target: localhost:8443", "TLS mode: separate files", "TLS server name: localhost", "key prefix: svc/", "dial timeout: 1s"} {
+		if !strings.Contains(description, want) {
+			t.Errorf("Describe() lacks %q:\n%s", want, description)
+		}
+	}
+	if strings.Contains(description, "hunter2") {
+		t.Errorf("Describe() reveals the secret:\n%s", description)
+	}
+}
+
+func TestSlowLogThreshold(t *testing.T) {
+	s := startMockServer(t)
+	s.set("fast", "value")
+	s.set("slow", "value")
+	s.beforeRetrieve = func(_ context.Context, req *pb.RetrieveRequest) error {
+		if req.GetKey() == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return nil
+	}
+	c := s.newClient(t, WithSlowLogThreshold(50*time.Millisecond))
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	if _, err := c.Retrieve("fast", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("fast call logged %q", buf.String())
+	}
+	if _, err := c.Retrieve("slow", testSecret); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, `slow parameter store retrieve of key "slow"`) || strings.Contains(out, "value") {
+		t.Errorf("slow call logged %q, want a warning naming the key only", out)
+	}
+}
+
+func TestAuditSink(t *testing.T) {
+	s := startMockServer(t)
+	var events []AuditEvent
+	c := s.newClient(t, WithAuditSink(func(event AuditEvent) {
+		events = append(events, event)
+	}))
+
+	c.Store("key", testSecret, "value")
+	c.Retrieve("key", testSecret)
+	c.Retrieve("missing", testSecret)
+	c.List("", testSecret)
+
+	want := []struct {
+		op, key string
+		failed  bool
+	}{
+		{"store", "key", false},
+		{"retrieve", "key", false},
+		{"retrieve", "missing", true},
+		{"list", "", false},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		event := events[i]
+		if event.Op != w.op || event.Key != w.key || (event.Err != nil) != w.failed {
+			t.Errorf("event %d = %+v, want op %q key %q failed %v", i, event, w.op, w.key, w.failed)
+		}
+		if event.Timestamp.IsZero() {
+			t.Errorf("event %d has no timestamp", i)
+		}
+	}
+	if !errors.Is(events[2].Err, ErrNotFound) {
+		t.Errorf("failed event Err = %v, want ErrNotFound", events[2].Err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
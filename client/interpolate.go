@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// referencePattern matches the ${NAME} references expanded by
+// WithInterpolation.
+var referencePattern = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// interpolate expands the ${NAME} references in value, first from the
+// environment and otherwise from the store key NAME, whose value is expanded
+// in turn. path holds the keys being expanded, outermost first, to detect
+// cycles.
+func (c *Client) interpolate(ctx context.Context, secret, value string, path []string) (string, error) {
+	var firstErr error
+	expanded := referencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		if firstErr != nil {
+			return reference
+		}
+		name := referencePattern.FindStringSubmatch(reference)[1]
+		if env, ok := os.LookupEnv(name); ok {
+			return env
+		}
+		for _, key := range path {
+			if key == name {
+				firstErr = fmt.Errorf("interpolation cycle: %s -> %s", strings.Join(path, " -> "), name)
+				return reference
+			}
+		}
+
+		nested, _, err := c.fetch(ctx, name, secret)
+		if errors.Is(err, ErrNotFound) {
+			firstErr = fmt.Errorf("unresolved reference ${%s} in key %q", name, path[len(path)-1])
+			return reference
+		}
+		if err == nil {
+			nested, err = c.interpolate(ctx, secret, nested, append(path[:len(path):len(path)], name))
+		}
+		if err != nil {
+			firstErr = err
+			return reference
+		}
+		return nested
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return expanded, nil
+}
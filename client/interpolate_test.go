@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	t.Setenv("PS_TEST_REGION", "eu-west-1")
+	s := startMockServer(t)
+	s.set("host", "db.${PS_TEST_REGION}.example.com")
+	s.set("user", "app")
+	s.set("dsn", "postgres://${user}@${host}/main")
+	s.set("plain", "no references")
+	c := s.newClient(t, WithInterpolation())
+
+	tests := map[string]string{
+		"host":  "db.eu-west-1.example.com",
+		"dsn":   "postgres://app@db.eu-west-1.example.com/main",
+		"plain": "no references",
+	}
+	for key, want := range tests {
+		if got, err := c.Retrieve(key, testSecret); err != nil || got != want {
+			t.Errorf("Retrieve(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	values, err := c.RetrieveBatchWithSecrets(map[string]string{"dsn": testSecret})
+	if err != nil || values["dsn"] != tests["dsn"] {
+		t.Errorf("RetrieveBatchWithSecrets = %v, %v; want dsn %q", values, err, tests["dsn"])
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	s := startMockServer(t)
+	s.set("a", "${b}")
+	s.set("b", "x${a}")
+	s.set("self", "${self}")
+	s.set("dangling", "${nowhere}")
+	c := s.newClient(t, WithInterpolation())
+
+	for key, want := range map[string]string{
+		"a":        "interpolation cycle: a -> b -> a",
+		"self":     "interpolation cycle: self -> self",
+		"dangling": "unresolved reference ${nowhere}",
+	} {
+		_, err := c.Retrieve(key, testSecret)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Retrieve(%q) = %v, want an error containing %q", key, err, want)
+		}
+	}
+}
+
+func TestInterpolationDisabledByDefault(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "${user}")
+	c := s.newClient(t)
+
+	if got, err := c.Retrieve("key", testSecret); err != nil || got != "${user}" {
+		t.Errorf("Retrieve = %q, %v; want the raw value", got, err)
+	}
+}
+
+func TestInterpolationSkippedWhenCopyingValues(t *testing.T) {
+	t.Setenv("PS_TEST_REGION", "eu-west-1")
+	const raw = "db.${PS_TEST_REGION}.example.com"
+	src := startMockServer(t)
+	dst := startMockServer(t)
+	srcClient := src.newClient(t, WithInterpolation())
+	dstClient := dst.newClient(t, WithInterpolation())
+
+	if err := srcClient.StoreVerified("host", testSecret, raw); err != nil {
+		t.Fatalf("StoreVerified of a value with a reference: %v", err)
+	}
+
+	if _, err := Migrate(context.Background(), srcClient, dstClient, []string{"host"}, testSecret, testSecret); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if value, _ := dst.get("host"); value != raw {
+		t.Errorf("Migrate copied %q, want the stored %q", value, raw)
+	}
+
+	report, err := SyncStores(context.Background(), srcClient, dstClient, testSecret, testSecret, "")
+	if err != nil || report != (SyncReport{Skipped: 1}) {
+		t.Errorf("SyncStores = %+v, %v; want the copied key skipped", report, err)
+	}
+}
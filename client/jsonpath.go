@@ -0,0 +1,55 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RetrieveJSONPath retrieves key, parses its value as JSON and returns the
+// element at jsonPath, a dotted path such as "db.primary.host". Path segments
+// that are integers index into arrays. A string element is returned as is;
+// any other element is returned as compact JSON.
+func (c *Client) RetrieveJSONPath(key, secret, jsonPath string) (string, error) {
+	value, err := c.Retrieve(key, secret)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return "", fmt.Errorf("value of key %q is not valid JSON: %w", key, err)
+	}
+
+	for _, segment := range strings.Split(jsonPath, ".") {
+		var ok bool
+		switch n := node.(type) {
+		case map[string]any:
+			node, ok = n[segment]
+		case []any:
+			i, convErr := strconv.Atoi(segment)
+			ok = convErr == nil && i >= 0 && i < len(n)
+			if ok {
+				node = n[i]
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("path %q not found in value of key %q", jsonPath, key)
+		}
+	}
+
+	if s, ok := node.(string); ok {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRetrieveJSONPath(t *testing.T) {
+	s := startMockServer(t)
+	s.set("config", `{"db": {"primary": {"host": "db1", "port": 5432}, "replicas": ["db2", "db3"]}, "big": 12345678901234567890}`)
+	s.set("text", "not json")
+	c := s.newClient(t)
+
+	tests := map[string]string{
+		"db.primary.host": "db1",
+		"db.primary.port": "5432",
+		"db.replicas.1":   "db3",
+		"db.primary":      `{"host":"db1","port":5432}`,
+		"big":             "12345678901234567890",
+	}
+	for path, want := range tests {
+		if got, err := c.RetrieveJSONPath("config", testSecret, path); err != nil || got != want {
+			t.Errorf("RetrieveJSONPath(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	for _, path := range []string{"db.secondary", "db.replicas.5", "db.replicas.x", "db.primary.host.name"} {
+		if _, err := c.RetrieveJSONPath("config", testSecret, path); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("RetrieveJSONPath(%q) = %v, want a not-found error", path, err)
+		}
+	}
+	if _, err := c.RetrieveJSONPath("text", testSecret, "a"); err == nil {
+		t.Error("RetrieveJSONPath on a non-JSON value succeeded")
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// KeyProvider encrypts values before they are stored and decrypts them after
+// they are retrieved, typically by calling out to a KMS. See WithKeyProvider.
+type KeyProvider interface {
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// AESGCMKeyProvider is a KeyProvider using AES-GCM with a static key. The
+// random nonce is prepended to each ciphertext.
+type AESGCMKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMKeyProvider returns an AESGCMKeyProvider for key, which must be
+// 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewAESGCMKeyProvider(key []byte) (*AESGCMKeyProvider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESGCMKeyProvider{aead: aead}, nil
+}
+
+func (p *AESGCMKeyProvider) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize(), p.aead.NonceSize()+len(plaintext)+p.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (p *AESGCMKeyProvider) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	nonceSize := p.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	return p.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+}
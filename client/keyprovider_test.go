@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyProviderRoundTrip(t *testing.T) {
+	provider, err := NewAESGCMKeyProvider(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewAESGCMKeyProvider: %v", err)
+	}
+	s := startMockServer(t)
+	c := s.newClient(t, WithKeyProvider(provider))
+
+	if err := c.Store("key", testSecret, "plaintext"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if raw, _ := s.get("key"); raw == "" || strings.Contains(raw, "plaintext") {
+		t.Errorf("server holds %q, want ciphertext", raw)
+	}
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "plaintext" {
+		t.Errorf("Retrieve = %q, %v; want %q", value, err, "plaintext")
+	}
+
+	other, _ := NewAESGCMKeyProvider(bytes.Repeat([]byte{8}, 32))
+	c2 := s.newClient(t, WithKeyProvider(other))
+	if _, err := c2.Retrieve("key", testSecret); err == nil {
+		t.Error("Retrieve with the wrong key succeeded")
+	}
+}
+
+func TestKeyProviderRejectsStreaming(t *testing.T) {
+	provider, _ := NewAESGCMKeyProvider(bytes.Repeat([]byte{7}, 16))
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithKeyProvider(provider))
+
+	if err := c.StoreLarge("key", testSecret, strings.NewReader("value")); !errors.Is(err, ErrStreamEncryption) {
+		t.Errorf("StoreLarge = %v, want ErrStreamEncryption", err)
+	}
+	var buf bytes.Buffer
+	if err := c.RetrieveLarge("key", testSecret, &buf); !errors.Is(err, ErrStreamEncryption) {
+		t.Errorf("RetrieveLarge = %v, want ErrStreamEncryption", err)
+	}
+	if value, _ := s.get("key"); value != "value" {
+		t.Errorf("StoreLarge wrote %q to the server", value)
+	}
+}
+
+func TestAESGCMKeyProvider(t *testing.T) {
+	if _, err := NewAESGCMKeyProvider([]byte("short")); err == nil {
+		t.Error("NewAESGCMKeyProvider accepted a 5-byte key")
+	}
+	provider, _ := NewAESGCMKeyProvider(bytes.Repeat([]byte{1}, 24))
+	ciphertext, err := provider.Encrypt(context.Background(), []byte("hello"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := provider.Decrypt(context.Background(), ciphertext); err == nil {
+		t.Error("Decrypt accepted a tampered ciphertext")
+	}
+	if _, err := provider.Decrypt(context.Background(), []byte{1}); err == nil {
+		t.Error("Decrypt accepted a truncated ciphertext")
+	}
+}
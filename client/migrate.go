@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Migrate copies keys from src to dst and returns how many were copied. Keys
+// missing from src are skipped; other per-key failures are collected and
+// returned together after the remaining keys have been tried. Cancelling ctx
+// stops the migration before the next key. Values are copied as stored:
+// ${NAME} references are not expanded, even if src uses WithInterpolation.
+func Migrate(ctx context.Context, src, dst *Client, keys []string, srcSecret, dstSecret string) (migrated int, err error) {
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		value, _, err := src.fetch(ctx, key, srcSecret)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retrieve %q: %w", key, err))
+			continue
+		}
+		if err := dst.StoreContext(ctx, key, dstSecret, value); err != nil {
+			errs = append(errs, fmt.Errorf("store %q: %w", key, err))
+			continue
+		}
+		migrated++
+	}
+	return migrated, errors.Join(errs...)
+}
+
+// SyncReport summarizes a SyncStores run.
+type SyncReport struct {
+	Copied  int
+	Skipped int
+	Failed  int
+}
+
+// SyncStores mirrors every key under prefix from src to dst, copying values
+// that are missing from dst or differ there and skipping those already in
+// sync. Keys are never deleted from dst. As with Migrate, values are copied
+// and compared as stored, without expanding ${NAME} references, and per-key
+// failures are counted and returned together after the remaining keys have
+// been tried.
+func SyncStores(ctx context.Context, src, dst *Client, srcSecret, dstSecret string, prefix string) (SyncReport, error) {
+	var report SyncReport
+	keys, err := src.List(prefix, srcSecret)
+	if err != nil {
+		return report, fmt.Errorf("list %q: %w", prefix, err)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		value, _, err := src.fetch(ctx, key, srcSecret)
+		if err != nil {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("retrieve %q: %w", key, err))
+			continue
+		}
+		current, _, err := dst.fetch(ctx, key, dstSecret)
+		if err == nil && current == value {
+			report.Skipped++
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("retrieve %q from destination: %w", key, err))
+			continue
+		}
+		if err := dst.StoreContext(ctx, key, dstSecret, value); err != nil {
+			report.Failed++
+			errs = append(errs, fmt.Errorf("store %q: %w", key, err))
+			continue
+		}
+		report.Copied++
+	}
+	return report, errors.Join(errs...)
+}
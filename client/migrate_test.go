@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	src := startMockServer(t)
+	dst := startMockServer(t)
+	dst.secrets = []string{"dst-secret"}
+	src.set("a", "1")
+	src.set("b", "2")
+
+	migrated, err := Migrate(context.Background(), src.newClient(t), dst.newClient(t), []string{"a", "b", "missing"}, testSecret, "dst-secret")
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("migrated = %d, want 2", migrated)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if value, _ := dst.get(key); value != want {
+			t.Errorf("destination %q = %q, want %q", key, value, want)
+		}
+	}
+	if _, ok := dst.get("missing"); ok {
+		t.Error("missing source key was created in the destination")
+	}
+
+	_, err = Migrate(context.Background(), src.newClient(t), dst.newClient(t), []string{"a"}, testSecret, "wrong")
+	if err == nil {
+		t.Error("Migrate with the wrong destination secret succeeded")
+	}
+}
+
+func TestSyncStores(t *testing.T) {
+	src := startMockServer(t)
+	dst := startMockServer(t)
+	src.set("app/same", "1")
+	src.set("app/changed", "new")
+	src.set("app/new", "3")
+	src.set("other/ignored", "4")
+	dst.set("app/same", "1")
+	dst.set("app/changed", "old")
+	dst.set("app/extra", "kept")
+
+	report, err := SyncStores(context.Background(), src.newClient(t), dst.newClient(t), testSecret, testSecret, "app/")
+	if err != nil {
+		t.Fatalf("SyncStores: %v", err)
+	}
+	if report != (SyncReport{Copied: 2, Skipped: 1}) {
+		t.Errorf("report = %+v, want 2 copied and 1 skipped", report)
+	}
+	for key, want := range map[string]string{"app/same": "1", "app/changed": "new", "app/new": "3", "app/extra": "kept"} {
+		if value, _ := dst.get(key); value != want {
+			t.Errorf("destination %q = %q, want %q", key, value, want)
+		}
+	}
+	if _, ok := dst.get("other/ignored"); ok {
+		t.Error("key outside the prefix was synced")
+	}
+
+	dst.down.Store(true)
+	report, err = SyncStores(context.Background(), src.newClient(t), dst.newClient(t), testSecret, testSecret, "app/")
+	if err == nil || report.Failed != 3 {
+		t.Errorf("SyncStores to an unavailable store = %+v, %v; want 3 failures", report, err)
+	}
+}
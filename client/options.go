@@ -0,0 +1,348 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
+)
+
+// ClientOption configures a Client created with NewClient. An error returned
+// by an option is returned from NewClient.
+type ClientOption func(*Client) error
+
+// WithContextDialer replaces the dialer used to open the underlying
+// connection, e.g. to bind a source address, use a unix socket or connect to
+// an in-memory bufconn listener in tests.
+func WithContextDialer(dialer func(context.Context, string) (net.Conn, error)) ClientOption {
+	return func(c *Client) error {
+		c.dialOptions = append(c.dialOptions, grpc.WithContextDialer(dialer))
+		return nil
+	}
+}
+
+// WithTLS secures the connection with tlsConfig. Passing nil is an error
+// rather than a silent fallback to an insecure connection, so a missing TLS
+// config fails NewClient instead of leaking the secret in plaintext.
+//
+// A tlsConfig holding P12 data is decoded by NewClient, so its password
+// callback runs once up front rather than inside the first call's timeout.
+func WithTLS(tlsConfig *TLSConfig) ClientOption {
+	return func(c *Client) error {
+		if tlsConfig == nil {
+			return errors.New("nil TLS config passed to WithTLS")
+		}
+		if c.TLSConfig != nil {
+			return errors.New("WithTLS cannot be combined with another TLS option")
+		}
+		c.TLSConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithTLSFromP12 secures the connection with the certificate, key and CA
+// chain from a PKCS#12 file. The file is read here and, like any P12
+// TLSConfig, decoded by NewClient, so passwordFn is called once before any
+// call's timeout starts and decode errors surface from NewClient.
+func WithTLSFromP12(p12File string, passwordFn PasswordCallback) ClientOption {
+	return func(c *Client) error {
+		if c.TLSConfig != nil {
+			return errors.New("WithTLSFromP12 cannot be combined with another TLS option")
+		}
+		p12Bytes, err := os.ReadFile(p12File)
+		if err != nil {
+			return fmt.Errorf("failed to read P12 file: %w", err)
+		}
+		c.TLSConfig = &TLSConfig{P12Bytes: p12Bytes, P12PasswordFn: passwordFn}
+		return nil
+	}
+}
+
+// WithRateLimit caps the client at rps calls per second with bursts of up to
+// burst calls. Calls wait for the limiter before dialing.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) error {
+		if rps <= 0 || burst <= 0 {
+			return fmt.Errorf("rate limit needs positive rps and burst, got %v and %d", rps, burst)
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		return nil
+	}
+}
+
+// WithAuthority sets the :authority header sent on every call independently
+// of the dial target, as some proxies and ingresses require. It does not
+// change the TLS server name.
+func WithAuthority(authority string) ClientOption {
+	return func(c *Client) error {
+		c.dialOptions = append(c.dialOptions, grpc.WithAuthority(authority))
+		return nil
+	}
+}
+
+// WithResolver resolves the client's host and port with builder, e.g. to
+// discover backends through a service registry, by dialing
+// "<scheme>:///host:port" where scheme is builder.Scheme(). The resolver is
+// only used by this client and is not registered globally.
+func WithResolver(builder resolver.Builder) ClientOption {
+	return func(c *Client) error {
+		if builder == nil {
+			return errors.New("resolver builder must not be nil")
+		}
+		c.resolverScheme = builder.Scheme()
+		c.dialOptions = append(c.dialOptions, grpc.WithResolvers(builder))
+		return nil
+	}
+}
+
+// CallOption adjusts a single RetrieveContext or StoreContext call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	serverName      string
+	grpcCallOptions []grpc.CallOption
+}
+
+func newCallOptions(opts []CallOption) callOptions {
+	var callOpts callOptions
+	for _, opt := range opts {
+		opt(&callOpts)
+	}
+	return callOpts
+}
+
+// WithServerName overrides the TLS server name for one call, e.g. to reach
+// one of several virtual parameter stores behind an SNI-routing endpoint. The
+// client's TLS configuration is cloned, not modified. It has no effect on
+// insecure clients.
+func WithServerName(serverName string) CallOption {
+	return func(callOpts *callOptions) {
+		callOpts.serverName = serverName
+	}
+}
+
+// WithPerRPCCredentials attaches creds, such as an OAuth bearer token, to
+// every call in addition to the store password. Credentials that require
+// transport security only work on a TLS-enabled client.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) ClientOption {
+	return func(c *Client) error {
+		c.dialOptions = append(c.dialOptions, grpc.WithPerRPCCredentials(creds))
+		return nil
+	}
+}
+
+// WithMetadataFromContext derives outgoing gRPC metadata from each call's
+// context, e.g. trace headers or a tenant ID stored by middleware. The pairs
+// are appended to any metadata already attached to the context.
+func WithMetadataFromContext(fn func(context.Context) map[string]string) ClientOption {
+	return func(c *Client) error {
+		c.metadataFromContext = fn
+		return nil
+	}
+}
+
+// WithDialTimeout bounds connection establishment separately from the RPC.
+// Each call first waits up to d for the connection to be ready, then runs the
+// RPC under the client's Timeout.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("dial timeout must be positive, got %s", d)
+		}
+		c.dialTimeout = d
+		return nil
+	}
+}
+
+// WithSingleFlight coalesces concurrent retrievals of the same key with the
+// same secret into one RPC whose result is shared by every caller. The shared
+// RPC keeps the values of the first caller's context but not its deadline or
+// cancellation, and is bounded by the client's Timeout; a caller whose own
+// context ends stops waiting and gets the context error.
+func WithSingleFlight() ClientOption {
+	return func(c *Client) error {
+		c.retrieveGroup = &singleflight.Group{}
+		return nil
+	}
+}
+
+// WithKeyPrefix prepends prefix to every key the client sends, so callers
+// can use short names such as "db-password" for "service-name/db-password".
+func WithKeyPrefix(prefix string) ClientOption {
+	return func(c *Client) error {
+		c.keyPrefix = prefix
+		return nil
+	}
+}
+
+// WithValueTransformer passes every retrieved value through fn before it is
+// returned, e.g. to decrypt an envelope-encrypted value. key is the key as
+// given by the caller, without any WithKeyPrefix prefix. An error from fn is
+// returned from the retrieval.
+func WithValueTransformer(fn func(key, raw string) (string, error)) ClientOption {
+	return func(c *Client) error {
+		c.valueTransformer = fn
+		return nil
+	}
+}
+
+// WithValueEncoder passes every value through fn before it is stored, as the
+// counterpart of WithValueTransformer. An error from fn aborts the store.
+func WithValueEncoder(fn func(key, value string) (string, error)) ClientOption {
+	return func(c *Client) error {
+		c.valueEncoder = fn
+		return nil
+	}
+}
+
+// WithKeyProvider encrypts every stored value with p and decrypts every
+// retrieved value with it. Ciphertexts are stored base64-encoded. The value
+// encoder runs before encryption and the value transformer after decryption.
+// The streaming StoreLarge and RetrieveLarge cannot encrypt and fail with
+// ErrStreamEncryption instead.
+func WithKeyProvider(p KeyProvider) ClientOption {
+	return func(c *Client) error {
+		if p == nil {
+			return errors.New("key provider must not be nil")
+		}
+		c.keyProvider = p
+		return nil
+	}
+}
+
+func withGrpcCallOptions(opts ...grpc.CallOption) CallOption {
+	return func(callOpts *callOptions) {
+		callOpts.grpcCallOptions = append(callOpts.grpcCallOptions, opts...)
+	}
+}
+
+// WithConnectionPool keeps size connections open and spreads calls across
+// them round-robin instead of dialing for every call, for workloads that
+// outgrow the stream limit of a single HTTP/2 connection. Connections are
+// dialed on first use; call Close to tear them down.
+func WithConnectionPool(size int) ClientOption {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("connection pool size must be positive, got %d", size)
+		}
+		c.pool = newConnPool(size)
+		return nil
+	}
+}
+
+// WithStrictCertValidation makes NewClient fail when the client certificate
+// is expired or not yet valid, instead of surfacing as a handshake failure on
+// the first call.
+func WithStrictCertValidation() ClientOption {
+	return func(c *Client) error {
+		c.strictCertValidation = true
+		return nil
+	}
+}
+
+// WithSecretInMetadata sends the secret in the headerName metadata header,
+// e.g. "authorization", instead of the request's password field, matching
+// how the REST client authenticates.
+func WithSecretInMetadata(headerName string) ClientOption {
+	return func(c *Client) error {
+		if headerName == "" {
+			return errors.New("secret header name must not be empty")
+		}
+		c.secretHeader = strings.ToLower(headerName)
+		return nil
+	}
+}
+
+// WithSlowLogThreshold logs a warning with the key and duration whenever a
+// Retrieve or Store takes longer than d. Values are never logged.
+func WithSlowLogThreshold(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.slowThreshold = d
+		return nil
+	}
+}
+
+// WithReadOnly makes every method that writes to the store fail with
+// ErrReadOnly before dialing, as a guardrail for processes that only consume
+// configuration.
+func WithReadOnly() ClientOption {
+	return func(c *Client) error {
+		c.readOnly = true
+		return nil
+	}
+}
+
+// WithAuditSink calls fn with an AuditEvent after every retrieve, store and
+// list operation, successful or not, for building an audit trail. Batch
+// stores report one event per key. fn is called synchronously on the calling
+// goroutine and must not block.
+func WithAuditSink(fn func(AuditEvent)) ClientOption {
+	return func(c *Client) error {
+		c.auditSink = fn
+		return nil
+	}
+}
+
+// WithDeadlinePropagation makes every call carry a deadline, which gRPC sends
+// to the server in the grpc-timeout header so it can bound work it forwards
+// to its own backends. The deadline comes from Timeout or the caller's
+// context; when neither sets one, calls are bounded by 30 seconds. Without
+// this option such calls have no deadline.
+func WithDeadlinePropagation(enabled bool) ClientOption {
+	return func(c *Client) error {
+		c.propagateDeadline = enabled
+		return nil
+	}
+}
+
+// WithCircuitBreaker stops calling the server after failureThreshold
+// consecutive calls fail because it is unavailable or too slow; later calls
+// fail at once with ErrCircuitOpen. After cooldown a single trial call is let
+// through: if it succeeds calls resume, otherwise the cooldown starts again.
+// Errors such as ErrNotFound or ErrUnauthenticated do not count as failures.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) error {
+		if failureThreshold <= 0 {
+			return fmt.Errorf("circuit breaker failure threshold must be positive, got %d", failureThreshold)
+		}
+		c.breaker = newCircuitBreaker(failureThreshold, cooldown)
+		return nil
+	}
+}
+
+// WithInterpolation expands ${NAME} references in retrieved values. NAME is
+// looked up in the environment first and otherwise retrieved from the store
+// with the same secret, with references in that value expanded in turn.
+// Retrieval fails on a reference that resolves nowhere or on a cycle of
+// references. RetrieveLarge streams values unchanged and does not expand
+// references, and neither do the read-backs of StoreVerified, Migrate and
+// SyncStores, which work on values as they are stored.
+func WithInterpolation() ClientOption {
+	return func(c *Client) error {
+		c.interpolation = true
+		return nil
+	}
+}
+
+// WithBackoff retries Retrieve and Store calls that fail because the server
+// is unavailable or too slow, up to b.MaxRetries times with b's delays. Other
+// errors, such as ErrNotFound, are returned at once. Streaming, batch and
+// list calls are not retried.
+func WithBackoff(b Backoff) ClientOption {
+	return func(c *Client) error {
+		if b.MaxRetries < 0 {
+			return fmt.Errorf("backoff max retries must not be negative, got %d", b.MaxRetries)
+		}
+		c.backoff = b
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+)
+
+// connPool holds a fixed number of lazily dialed connections that calls are
+// spread across round-robin.
+type connPool struct {
+	slots []poolSlot
+	next  atomic.Uint64
+}
+
+type poolSlot struct {
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+func newConnPool(size int) *connPool {
+	return &connPool{slots: make([]poolSlot, size)}
+}
+
+// get returns the next connection in round-robin order, dialing it with dial
+// the first time its slot is used.
+func (p *connPool) get(ctx context.Context, dial func(context.Context) (*grpc.ClientConn, error)) (*grpc.ClientConn, error) {
+	slot := &p.slots[(p.next.Add(1)-1)%uint64(len(p.slots))]
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+
+	if slot.conn == nil {
+		conn, err := dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		slot.conn = conn
+	}
+	return slot.conn, nil
+}
+
+// close closes every dialed connection. Slots are emptied so that a later
+// get dials again.
+func (p *connPool) close() error {
+	var errs []error
+	for i := range p.slots {
+		slot := &p.slots[i]
+		slot.mu.Lock()
+		if slot.conn != nil {
+			errs = append(errs, slot.conn.Close())
+			slot.conn = nil
+		}
+		slot.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
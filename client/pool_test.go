@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestConnectionPool(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t, WithConnectionPool(3))
+
+	for i := 0; i < 9; i++ {
+		if _, err := c.Retrieve("key", testSecret); err != nil {
+			t.Fatalf("Retrieve: %v", err)
+		}
+	}
+	if n := s.dials.Load(); n != 3 {
+		t.Errorf("9 calls on a pool of 3 dialed %d connections, want 3", n)
+	}
+	for i := range c.pool.slots {
+		if c.pool.slots[i].conn == nil {
+			t.Errorf("pool slot %d was never used", i)
+		}
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for i := range c.pool.slots {
+		if c.pool.slots[i].conn != nil {
+			t.Errorf("pool slot %d still holds a connection after Close", i)
+		}
+	}
+	if _, err := c.Retrieve("key", testSecret); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Retrieve after Close = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestWithoutConnectionPoolDialsPerCall(t *testing.T) {
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Retrieve("key", testSecret); err != nil {
+			t.Fatalf("Retrieve: %v", err)
+		}
+	}
+	if n := s.dials.Load(); n != 3 {
+		t.Errorf("3 calls dialed %d connections, want 3", n)
+	}
+}
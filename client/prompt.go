@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// TerminalPromptContext returns a PasswordCallback that prompts for the P12
+// password on the terminal without echoing it, and gives up with ctx.Err()
+// once ctx is done. If ctx is already done it returns at once, without
+// prompting or reading.
+//
+// Reading from the terminal cannot be interrupted, so after a cancellation
+// mid-prompt the read keeps running in the background and will still consume
+// the next line typed on stdin.
+func TerminalPromptContext(ctx context.Context) PasswordCallback {
+	return promptContext(ctx, os.Stderr, func() ([]byte, error) {
+		return term.ReadPassword(int(os.Stdin.Fd()))
+	})
+}
+
+// promptContext implements TerminalPromptContext, writing the prompt to out
+// and reading the password with readPassword.
+func promptContext(ctx context.Context, out io.Writer, readPassword func() ([]byte, error)) PasswordCallback {
+	return func() (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		type result struct {
+			password []byte
+			err      error
+		}
+		done := make(chan result, 1)
+
+		fmt.Fprint(out, "Enter P12 password: ")
+		go func() {
+			password, err := readPassword()
+			done <- result{password, err}
+		}()
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return "", ctx.Err()
+		case r := <-done:
+			fmt.Fprintln(out)
+			if r.err != nil {
+				return "", fmt.Errorf("failed to read password: %w", r.err)
+			}
+			return string(r.password), nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPromptContext(t *testing.T) {
+	var out bytes.Buffer
+	password, err := promptContext(context.Background(), &out, func() ([]byte, error) {
+		return []byte("pw"), nil
+	})()
+	if err != nil || password != "pw" {
+		t.Errorf("prompt = %q, %v; want %q", password, err, "pw")
+	}
+	if !strings.Contains(out.String(), "Enter P12 password") {
+		t.Errorf("prompt wrote %q, want the password prompt", out.String())
+	}
+
+	readErr := errors.New("not a terminal")
+	_, err = promptContext(context.Background(), &out, func() ([]byte, error) {
+		return nil, readErr
+	})()
+	if !errors.Is(err, readErr) {
+		t.Errorf("prompt with a failing read = %v, want the read error", err)
+	}
+}
+
+func TestPromptContextAlreadyDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	read := false
+	_, err := promptContext(ctx, &out, func() ([]byte, error) {
+		read = true
+		return []byte("pw"), nil
+	})()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("prompt = %v, want context.Canceled", err)
+	}
+	if read || out.Len() != 0 {
+		t.Errorf("prompt with a done context read %v and wrote %q, want neither", read, out.String())
+	}
+}
+
+func TestPromptContextCancelledWhileReading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reading := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	go func() {
+		<-reading
+		cancel()
+	}()
+	_, err := promptContext(ctx, &bytes.Buffer{}, func() ([]byte, error) {
+		close(reading)
+		<-release
+		return []byte("pw"), nil
+	})()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("prompt = %v, want context.Canceled", err)
+	}
+}
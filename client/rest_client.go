@@ -2,83 +2,466 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
 )
 
 type APIClient struct {
 	BaseURL                string
 	AuthenticationPassword string
+
+	gzip            bool
+	idempotencyKeys bool
+	// storeStatuses are the response statuses Store treats as success; nil
+	// means defaultStoreStatuses.
+	storeStatuses []int
+	// fieldNames holds the WithJSONFieldNames names; empty names mean the
+	// defaults.
+	fieldNames JSONFieldNames
+	// maxResponseBytes caps the decoded size of a Retrieve response body.
+	// Zero means defaultMaxResponseBytes and a negative value means no limit.
+	maxResponseBytes int64
+	// httpClient is nil, meaning http.DefaultClient, unless an option
+	// installs a client with its own transport, which Close then releases.
+	httpClient *http.Client
+}
+
+type APIClientOption func(*APIClient)
+
+// WithGzipCompression gzips request bodies sent by Store and asks the server
+// for gzipped responses in Retrieve. The server must support gzip encoding.
+func WithGzipCompression() APIClientOption {
+	return func(client *APIClient) {
+		client.gzip = true
+	}
+}
+
+// WithHTTP2PriorKnowledge makes the client speak HTTP/2 without first
+// negotiating it, multiplexing calls over a single connection. For http://
+// base URLs this is cleartext HTTP/2 (h2c); for https:// base URLs HTTP/2 is
+// used over TLS. The server must support it. Call Close to release the
+// connection.
+func WithHTTP2PriorKnowledge() APIClientOption {
+	return func(client *APIClient) {
+		transport := &http2.Transport{}
+		if !strings.HasPrefix(client.BaseURL, "https://") {
+			transport.AllowHTTP = true
+			transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			}
+		}
+		client.httpClient = &http.Client{Transport: transport}
+	}
+}
+
+// defaultStoreStatuses are the statuses Store accepts unless
+// WithStoreSuccessStatuses sets others.
+var defaultStoreStatuses = []int{http.StatusOK, http.StatusCreated, http.StatusNoContent}
+
+// WithStoreSuccessStatuses replaces the response statuses Store accepts as
+// success, which default to 200, 201 and 204. With no statuses the default
+// is kept.
+func WithStoreSuccessStatuses(statuses ...int) APIClientOption {
+	return func(client *APIClient) {
+		client.storeStatuses = statuses
+	}
+}
+
+// JSONFieldNames names the fields of the JSON bodies exchanged with the REST
+// gateway.
+type JSONFieldNames struct {
+	// Key and Value name the fields of the Store request body; Value also
+	// names the field read from Retrieve responses. They default to "key"
+	// and "value".
+	Key   string
+	Value string
+	// Password, if set, adds the authentication password to the Store
+	// request body under this name. It is always sent in the Authorization
+	// header.
+	Password string
+}
+
+// WithJSONFieldNames replaces the JSON field names used in request and
+// response bodies, for gateways that expect e.g. {"name": ..., "data": ...}.
+// Empty Key and Value fields keep their defaults.
+func WithJSONFieldNames(names JSONFieldNames) APIClientOption {
+	return func(client *APIClient) {
+		client.fieldNames = names
+	}
+}
+
+// jsonFieldNames returns the field names to use, with empty Key and Value
+// names replaced by the defaults.
+func (client *APIClient) jsonFieldNames() JSONFieldNames {
+	names := client.fieldNames
+	if names.Key == "" {
+		names.Key = "key"
+	}
+	if names.Value == "" {
+		names.Value = "value"
+	}
+	return names
+}
+
+// defaultMaxResponseBytes is the Retrieve response body limit used unless
+// WithMaxResponseBytes sets another.
+const defaultMaxResponseBytes = 4 << 20
+
+// WithMaxResponseBytes limits how much of a Retrieve response body, after
+// gzip decoding, is read. Larger responses fail with ErrResponseTooLarge. The
+// default is 4 MiB; n <= 0 removes the limit.
+func WithMaxResponseBytes(n int64) APIClientOption {
+	return func(client *APIClient) {
+		if n <= 0 {
+			n = -1
+		}
+		client.maxResponseBytes = n
+	}
 }
 
-func NewAPIClient(baseURL, authenticationPassword string) *APIClient {
-	return &APIClient{
+// WithIdempotencyKeys makes every Store send a freshly generated
+// Idempotency-Key header, unless the call supplies its own key with
+// WithIdempotencyKey.
+func WithIdempotencyKeys() APIClientOption {
+	return func(client *APIClient) {
+		client.idempotencyKeys = true
+	}
+}
+
+// StoreOption adjusts a single APIClient.StoreContext call.
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	idempotencyKey string
+}
+
+// WithIdempotencyKey sends idempotencyKey in the Idempotency-Key header so a
+// gateway can deduplicate retries. Reuse the same key when retrying the same
+// logical store.
+func WithIdempotencyKey(idempotencyKey string) StoreOption {
+	return func(opts *storeOptions) {
+		opts.idempotencyKey = idempotencyKey
+	}
+}
+
+// NewIdempotencyKey returns a random UUID (version 4) suitable for
+// WithIdempotencyKey.
+func NewIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func NewAPIClient(baseURL, authenticationPassword string, opts ...APIClientOption) *APIClient {
+	client := &APIClient{
 		BaseURL:                baseURL,
 		AuthenticationPassword: authenticationPassword,
 	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// String implements fmt.Stringer so that printing an APIClient never reveals
+// the authentication password.
+func (client *APIClient) String() string {
+	return fmt.Sprintf("APIClient{BaseURL:%s AuthenticationPassword:%s}", client.BaseURL, redact(client.AuthenticationPassword))
+}
+
+// Close releases idle connections held by a transport the APIClient created.
+// It is a no-op for clients using http.DefaultClient, which is shared.
+func (client *APIClient) Close() {
+	if client.httpClient != nil {
+		client.httpClient.CloseIdleConnections()
+	}
+}
+
+// doer returns the http.Client requests are sent with. APIClient values built
+// without NewAPIClient use http.DefaultClient.
+func (client *APIClient) doer() *http.Client {
+	if client.httpClient == nil {
+		return http.DefaultClient
+	}
+	return client.httpClient
 }
 
 func (client *APIClient) Store(key, value string) error {
+	return client.StoreContext(context.Background(), key, value)
+}
+
+func (client *APIClient) StoreContext(ctx context.Context, key, value string, opts ...StoreOption) error {
+	accepted := client.storeStatuses
+	if accepted == nil {
+		accepted = defaultStoreStatuses
+	}
+	return client.sendValue(ctx, "POST", key, value, opts, accepted, "failed to create resource")
+}
+
+// Update replaces the value of an existing key with a PUT request, for
+// gateways that reserve POST for creation. 200 and 204 are accepted as
+// success.
+func (client *APIClient) Update(key, value string) error {
+	return client.sendValue(context.Background(), "PUT", key, value, nil, []int{http.StatusOK, http.StatusNoContent}, "failed to update resource")
+}
+
+// sendValue sends key and value to the store endpoint with method and
+// succeeds when the response status is one of accepted.
+func (client *APIClient) sendValue(ctx context.Context, method, key, value string, opts []StoreOption, accepted []int, failure string) error {
+	var storeOpts storeOptions
+	for _, opt := range opts {
+		opt(&storeOpts)
+	}
+	if storeOpts.idempotencyKey == "" && client.idempotencyKeys {
+		idempotencyKey, err := NewIdempotencyKey()
+		if err != nil {
+			return err
+		}
+		storeOpts.idempotencyKey = idempotencyKey
+	}
+
 	url := fmt.Sprintf("%s/store", client.BaseURL)
+	names := client.jsonFieldNames()
 	data := map[string]string{
-		"key":   key,
-		"value": value,
+		names.Key:   key,
+		names.Value: value,
+	}
+	if names.Password != "" {
+		data[names.Password] = client.AuthenticationPassword
 	}
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if client.gzip {
+		jsonData, err = gzipBytes(jsonData)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if client.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 	req.Header.Set("Authorization", client.AuthenticationPassword)
-	resp, err := http.DefaultClient.Do(req)
+	if storeOpts.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", storeOpts.idempotencyKey)
+	}
+	resp, err := client.doer().Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusCreated {
-		return errors.New("failed to create resource")
-
+	if !slices.Contains(accepted, resp.StatusCode) {
+		return httpStatusError(resp.StatusCode, errors.New(failure))
 	}
 	return nil
 }
 
-func (client *APIClient) Retrieve(key string) (string, error) {
+func (client *APIClient) newRetrieveRequest(ctx context.Context, key string) (*http.Request, error) {
 	url := fmt.Sprintf("%s/retrieve?key=%s", client.BaseURL, key)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Set("Authorization", client.AuthenticationPassword)
-	resp, err := http.DefaultClient.Do(req)
+	return req, nil
+}
+
+// RetrieveRaw sends the retrieve request and returns the response as is, for
+// callers that need headers such as ETag or Cache-Control. The status code is
+// not checked and the body is left unread; the caller owns the body and must
+// close it.
+func (client *APIClient) RetrieveRaw(ctx context.Context, key string) (*http.Response, error) {
+	req, err := client.newRetrieveRequest(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return client.doer().Do(req)
+}
+
+func (client *APIClient) Retrieve(key string) (string, error) {
+	return client.RetrieveContext(context.Background(), key)
+}
+
+// RetrieveContext is like Retrieve but bounded by ctx.
+func (client *APIClient) RetrieveContext(ctx context.Context, key string) (string, error) {
+	req, err := client.newRetrieveRequest(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if client.gzip {
+		// Setting the header ourselves disables the transport's transparent
+		// decompression, so the body is decoded below.
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := client.doer().Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("failed to retrieve data")
+		return "", httpStatusError(resp.StatusCode, errors.New("failed to retrieve data"))
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	var reader io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
 	}
 
-	var result map[string]string
-	err = json.Unmarshal(body, &result)
+	limit := client.maxResponseBytes
+	if limit == 0 {
+		limit = defaultMaxResponseBytes
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	body, err := io.ReadAll(reader)
 	if err != nil {
 		return "", err
 	}
+	if limit > 0 && int64(len(body)) > limit {
+		return "", fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, limit)
+	}
+
+	return decodeRetrieveResponse(body, client.jsonFieldNames().Value)
+}
+
+// retrieveManyConcurrency is the number of requests RetrieveMany has in
+// flight at once.
+const retrieveManyConcurrency = 8
+
+// RetrieveMany retrieves keys concurrently, with at most 8 requests in flight,
+// reusing the client's connections. The values that could be retrieved are
+// returned together with the per-key failures joined into one error.
+func (client *APIClient) RetrieveMany(ctx context.Context, keys []string) (map[string]string, error) {
+	var (
+		mu     sync.Mutex
+		values = make(map[string]string, len(keys))
+		errs   []error
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, retrieveManyConcurrency)
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			value, err := client.RetrieveContext(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("retrieve %q: %w", key, err))
+				return
+			}
+			values[key] = value
+		}()
+	}
+	wg.Wait()
+	return values, errors.Join(errs...)
+}
+
+// maxKeysInError caps how many top-level keys of an unexpected response are
+// named in an error.
+const maxKeysInError = 10
+
+// decodeRetrieveResponse extracts the value from a retrieve response body,
+// accepting both an object holding it in valueField and a bare JSON string.
+func decodeRetrieveResponse(body []byte, valueField string) (string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err == nil {
+		var value string
+		if raw, ok := object[valueField]; ok && json.Unmarshal(raw, &value) == nil {
+			return value, nil
+		}
+	}
+	var value string
+	if err := json.Unmarshal(body, &value); err == nil {
+		return value, nil
+	}
+
+	return "", fmt.Errorf(`unexpected retrieve response, want {%q: "..."} or a JSON string, got %s`, valueField, describeBody(body))
+}
 
-	return result["value"], nil
+// describeBody describes the shape of a response body for an error message:
+// its JSON type, the top-level keys of an object and its length. The body
+// itself may hold the secret value, so none of it is quoted.
+func describeBody(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+		return fmt.Sprintf("a non-JSON body of %d bytes", len(body))
+	}
+	switch decoded := decoded.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(decoded))
+		for key := range decoded {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		if len(keys) > maxKeysInError {
+			keys = append(keys[:maxKeysInError], "...")
+		}
+		return fmt.Sprintf("a JSON object with keys %q (%d bytes)", keys, len(body))
+	case []any:
+		return fmt.Sprintf("a JSON array (%d bytes)", len(body))
+	case json.Number:
+		return fmt.Sprintf("a JSON number (%d bytes)", len(body))
+	case bool:
+		return fmt.Sprintf("a JSON boolean (%d bytes)", len(body))
+	default:
+		return "JSON null"
+	}
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "****"
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 func RestSimpleRetrieve(ServerAddress string, AuthenticationPassword string, key string) (val string, err error) {
@@ -0,0 +1,473 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// restStore is an in-memory REST parameter store speaking the JSON API used
+// by APIClient.
+type restStore struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newRESTStore(t *testing.T) (*restStore, *httptest.Server) {
+	t.Helper()
+	store := &restStore{values: make(map[string]string)}
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+	return store, srv
+}
+
+func (s *restStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != testSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/store":
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.values[body["key"]] = body["value"]
+		w.WriteHeader(http.StatusCreated)
+	case "/retrieve":
+		value, ok := s.values[r.URL.Query().Get("key")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"value": value})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestAPIClientStoreAndRetrieve(t *testing.T) {
+	_, srv := newRESTStore(t)
+	client := NewAPIClient(srv.URL, testSecret)
+	defer client.Close()
+
+	if err := client.Store("key", "value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if value, err := client.Retrieve("key"); err != nil || value != "value" {
+		t.Errorf("Retrieve = %q, %v", value, err)
+	}
+	if _, err := client.Retrieve("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Retrieve of missing key = %v, want ErrNotFound", err)
+	}
+}
+
+func TestZeroValueAPIClient(t *testing.T) {
+	store, srv := newRESTStore(t)
+	store.values["key"] = "value"
+	client := &APIClient{BaseURL: srv.URL, AuthenticationPassword: testSecret}
+	defer client.Close()
+
+	resp, err := client.RetrieveRaw(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("RetrieveRaw: %v", err)
+	}
+	resp.Body.Close()
+	if value, err := client.Retrieve("key"); err != nil || value != "value" {
+		t.Errorf("Retrieve = %q, %v", value, err)
+	}
+	if err := client.Store("key", "new"); err != nil {
+		t.Errorf("Store: %v", err)
+	}
+}
+
+func TestAPIClientStatusErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusUnauthorized, ErrUnauthenticated},
+		{http.StatusForbidden, ErrUnauthenticated},
+		{http.StatusInternalServerError, ErrUnavailable},
+		{http.StatusServiceUnavailable, ErrUnavailable},
+		{http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		client := NewAPIClient(srv.URL, testSecret)
+
+		_, retrieveErr := client.Retrieve("key")
+		storeErr := client.Store("key", "value")
+		for op, err := range map[string]error{"Retrieve": retrieveErr, "Store": storeErr} {
+			if err == nil {
+				t.Errorf("%s with HTTP %d succeeded", op, tt.status)
+				continue
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("%s with HTTP %d = %v, want %v", op, tt.status, err, tt.want)
+			}
+			if tt.want == nil && (errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrUnavailable)) {
+				t.Errorf("%s with HTTP %d = %v, want no sentinel", op, tt.status, err)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func TestAPIClientGzip(t *testing.T) {
+	var stored map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/store":
+			if r.Header.Get("Content-Encoding") != "gzip" {
+				t.Errorf("store Content-Encoding = %q, want gzip", r.Header.Get("Content-Encoding"))
+			}
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("store body is not gzipped: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewDecoder(reader).Decode(&stored)
+			w.WriteHeader(http.StatusCreated)
+		case "/retrieve":
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				t.Errorf("retrieve Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			writer := gzip.NewWriter(w)
+			json.NewEncoder(writer).Encode(map[string]string{"value": stored["value"]})
+			writer.Close()
+		}
+	}))
+	defer srv.Close()
+	client := NewAPIClient(srv.URL, testSecret, WithGzipCompression())
+
+	if err := client.Store("key", "compressed value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if stored["key"] != "key" || stored["value"] != "compressed value" {
+		t.Errorf("server decoded %v", stored)
+	}
+	if value, err := client.Retrieve("key"); err != nil || value != "compressed value" {
+		t.Errorf("Retrieve = %q, %v", value, err)
+	}
+}
+
+func TestAPIClientH2C(t *testing.T) {
+	store := &restStore{values: map[string]string{"key": "value"}}
+	var protoMajor atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protoMajor.Store(int32(r.ProtoMajor))
+		store.ServeHTTP(w, r)
+	})
+	srv := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer srv.Close()
+	client := NewAPIClient(srv.URL, testSecret, WithHTTP2PriorKnowledge())
+	defer client.Close()
+
+	if value, err := client.Retrieve("key"); err != nil || value != "value" {
+		t.Fatalf("Retrieve = %q, %v", value, err)
+	}
+	if protoMajor.Load() != 2 {
+		t.Errorf("request used HTTP/%d, want HTTP/2", protoMajor.Load())
+	}
+}
+
+func TestAPIClientIdempotencyKey(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	client := NewAPIClient(srv.URL, testSecret)
+	client.Store("key", "value")
+	key, err := NewIdempotencyKey()
+	if err != nil {
+		t.Fatalf("NewIdempotencyKey: %v", err)
+	}
+	// Retrying the same logical store reuses its key.
+	for i := 0; i < 2; i++ {
+		client.StoreContext(context.Background(), "key", "value", WithIdempotencyKey(key))
+	}
+
+	generating := NewAPIClient(srv.URL, testSecret, WithIdempotencyKeys())
+	generating.Store("key", "value")
+	generating.Store("key", "value")
+
+	if keys[0] != "" {
+		t.Errorf("default client sent Idempotency-Key %q", keys[0])
+	}
+	if keys[1] != key || keys[2] != key {
+		t.Errorf("retries sent keys %q and %q, want %q twice", keys[1], keys[2], key)
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !uuid.MatchString(keys[3]) || !uuid.MatchString(keys[4]) || keys[3] == keys[4] {
+		t.Errorf("generated keys %q and %q, want two distinct v4 UUIDs", keys[3], keys[4])
+	}
+}
+
+func TestAPIClientStoreStatuses(t *testing.T) {
+	var status atomic.Int32
+	var method atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method.Store(r.Method)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		opts     []APIClientOption
+		accepted []int
+		rejected []int
+	}{
+		{"default", nil, []int{200, 201, 204}, []int{202, 302}},
+		{"custom", []APIClientOption{WithStoreSuccessStatuses(202)}, []int{202}, []int{200, 201}},
+		{"empty keeps default", []APIClientOption{WithStoreSuccessStatuses()}, []int{200, 201, 204}, []int{202}},
+	}
+	for _, tt := range tests {
+		client := NewAPIClient(srv.URL, testSecret, tt.opts...)
+		for _, code := range tt.accepted {
+			status.Store(int32(code))
+			if err := client.Store("key", "value"); err != nil {
+				t.Errorf("%s: Store with HTTP %d = %v, want success", tt.name, code, err)
+			}
+		}
+		for _, code := range tt.rejected {
+			status.Store(int32(code))
+			if err := client.Store("key", "value"); err == nil {
+				t.Errorf("%s: Store with HTTP %d succeeded", tt.name, code)
+			}
+		}
+	}
+	if method.Load() != http.MethodPost {
+		t.Errorf("Store used %v, want POST", method.Load())
+	}
+}
+
+func TestAPIClientUpdate(t *testing.T) {
+	var status atomic.Int32
+	var method atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method.Store(r.Method)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	client := NewAPIClient(srv.URL, testSecret)
+	for code, ok := range map[int]bool{200: true, 204: true, 201: false} {
+		status.Store(int32(code))
+		if err := client.Update("key", "value"); (err == nil) != ok {
+			t.Errorf("Update with HTTP %d = %v, want success %v", code, err, ok)
+		}
+		if method.Load() != http.MethodPut {
+			t.Errorf("Update used %v, want PUT", method.Load())
+		}
+	}
+}
+
+func TestAPIClientRetrieveRaw(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v7"`)
+		w.Write([]byte(`{"value": "value"}`))
+	}))
+	defer srv.Close()
+	client := NewAPIClient(srv.URL, testSecret)
+
+	resp, err := client.RetrieveRaw(context.Background(), "key")
+	if err != nil {
+		t.Fatalf("RetrieveRaw: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("ETag") != `"v7"` {
+		t.Errorf("ETag = %q", resp.Header.Get("ETag"))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != `{"value": "value"}` {
+		t.Errorf("body = %q, %v", body, err)
+	}
+}
+
+func TestAPIClientResponseShapes(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    string
+		wantErr string
+	}{
+		{body: `{"value": "object"}`, want: "object"},
+		{body: `"bare string"`, want: "bare string"},
+		{body: `{"other": "hunter2"}`, wantErr: `object with keys ["other"]`},
+		{body: `{"value": 12345}`, wantErr: `object with keys ["value"]`},
+		{body: `["hunter2"]`, wantErr: "JSON array"},
+		{body: `hunter2`, wantErr: "non-JSON body of 7 bytes"},
+		{body: `{"value": ` + strings.Repeat("9", 1000) + `}`, wantErr: "1011 bytes"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.body))
+		}))
+		value, err := NewAPIClient(srv.URL, testSecret).Retrieve("key")
+		srv.Close()
+
+		if tt.wantErr == "" && (err != nil || value != tt.want) {
+			t.Errorf("body %.20s: Retrieve = %q, %v; want %q", tt.body, value, err, tt.want)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("body %.20s: Retrieve = %q, %v; want an error containing %q", tt.body, value, err, tt.wantErr)
+		}
+		if err != nil && (strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "12345")) {
+			t.Errorf("body %.20s: error %q quotes the response body", tt.body, err)
+		}
+	}
+}
+
+func TestAPIClientMaxResponseBytes(t *testing.T) {
+	large := `"` + strings.Repeat("x", defaultMaxResponseBytes) + `"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") == "large" {
+			w.Write([]byte(large))
+			return
+		}
+		w.Write([]byte(`{"value": "0123456789"}`))
+	}))
+	defer srv.Close()
+
+	limited := NewAPIClient(srv.URL, testSecret, WithMaxResponseBytes(10))
+	if _, err := limited.Retrieve("small"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Retrieve past a 10 byte limit = %v, want ErrResponseTooLarge", err)
+	}
+	if _, err := NewAPIClient(srv.URL, testSecret).Retrieve("large"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Retrieve past the default limit = %v, want ErrResponseTooLarge", err)
+	}
+	for _, n := range []int64{0, -1} {
+		client := NewAPIClient(srv.URL, testSecret, WithMaxResponseBytes(n))
+		if value, err := client.Retrieve("large"); err != nil || len(value) != defaultMaxResponseBytes {
+			t.Errorf("Retrieve with WithMaxResponseBytes(%d) = %d bytes, %v; want no limit", n, len(value), err)
+		}
+	}
+}
+
+func TestAPIClientJSONFieldNames(t *testing.T) {
+	var stored map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/store":
+			stored = nil
+			json.NewDecoder(r.Body).Decode(&stored)
+			w.WriteHeader(http.StatusCreated)
+		case "/retrieve":
+			json.NewEncoder(w).Encode(map[string]string{"data": "custom", "value": "default"})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewAPIClient(srv.URL, testSecret, WithJSONFieldNames(JSONFieldNames{Key: "name", Value: "data", Password: "token"}))
+	if err := client.Store("key", "value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	want := map[string]string{"name": "key", "data": "value", "token": testSecret}
+	if fmt.Sprint(stored) != fmt.Sprint(want) {
+		t.Errorf("store body = %v, want %v", stored, want)
+	}
+	if value, err := client.Retrieve("key"); err != nil || value != "custom" {
+		t.Errorf("Retrieve = %q, %v; want %q", value, err, "custom")
+	}
+
+	partial := NewAPIClient(srv.URL, testSecret, WithJSONFieldNames(JSONFieldNames{Value: "data"}))
+	if err := partial.Store("key", "value"); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	want = map[string]string{"key": "key", "data": "value"}
+	if fmt.Sprint(stored) != fmt.Sprint(want) {
+		t.Errorf("store body with default key name = %v, want %v", stored, want)
+	}
+}
+
+func TestAPIClientRetrieveMany(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		key := r.URL.Query().Get("key")
+		if key == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"value": "value of " + key})
+	}))
+	defer srv.Close()
+	client := NewAPIClient(srv.URL, testSecret)
+
+	keys := []string{"missing"}
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	values, err := client.RetrieveMany(context.Background(), keys)
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("RetrieveMany err = %v, want ErrNotFound for missing", err)
+	}
+	if len(values) != 20 || values["key7"] != "value of key7" {
+		t.Errorf("RetrieveMany returned %d values, key7 = %q", len(values), values["key7"])
+	}
+	if max := maxInFlight.Load(); max < 2 || max > retrieveManyConcurrency {
+		t.Errorf("max concurrent requests = %d, want between 2 and %d", max, retrieveManyConcurrency)
+	}
+}
+
+func TestAPIClientStringRedactsPassword(t *testing.T) {
+	client := NewAPIClient("http://localhost", "hunter2")
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		if out := fmt.Sprintf(format, client); strings.Contains(out, "hunter2") {
+			t.Errorf("%s formatting revealed the password: %s", format, out)
+		}
+	}
+}
+
+func TestGzipBytes(t *testing.T) {
+	compressed, err := gzipBytes([]byte("hello"))
+	if err != nil {
+		t.Fatalf("gzipBytes: %v", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	if out, _ := io.ReadAll(reader); string(out) != "hello" {
+		t.Errorf("round trip = %q", out)
+	}
+}
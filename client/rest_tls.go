@@ -0,0 +1,88 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewAPIClientWithReloadingMTLS creates an APIClient that authenticates with
+// the client certificate from tlsConfig. The certificate and key files are
+// re-read whenever their modification time changes, so a long-lived client
+// presents a rotated certificate on its next TLS handshake without being
+// rebuilt. Call Close to release the client's connections.
+func NewAPIClientWithReloadingMTLS(baseURL, authenticationPassword string, tlsConfig *TLSConfig, opts ...APIClientOption) (*APIClient, error) {
+	if tlsConfig == nil || tlsConfig.ClientCertFile == "" {
+		return nil, errors.New("a TLS config with a client certificate is required")
+	}
+	baseConfig, err := tlsConfig.GetTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	reloader := &certReloader{
+		certFile: tlsConfig.ClientCertFile,
+		keyFile:  tlsConfig.ClientKeyFile,
+	}
+	if _, err := reloader.getClientCertificate(nil); err != nil {
+		return nil, err
+	}
+	baseConfig.Certificates = nil
+	baseConfig.GetClientCertificate = reloader.getClientCertificate
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = baseConfig
+
+	client := NewAPIClient(baseURL, authenticationPassword, opts...)
+	client.httpClient = &http.Client{Transport: transport}
+	return client, nil
+}
+
+// certReloader loads a key pair from disk and reloads it when either file
+// changes.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cert == nil || !modTime.Equal(r.modTime) {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		r.cert = &cert
+		r.modTime = modTime
+	}
+	return r.cert, nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("TLS file %s is not accessible: %w", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReloadingMTLSPresentsRotatedCert(t *testing.T) {
+	serverTLS, clientTLS := tlsServerConfig(t)
+	_, rotatedTLS := tlsServerConfig(t)
+	rotatedCA, err := os.ReadFile(rotatedTLS.CAFile)
+	if err != nil {
+		t.Fatalf("read CA: %v", err)
+	}
+	// Trust both client CAs so the server accepts the rotated certificate.
+	serverTLS.ClientCAs.AppendCertsFromPEM(rotatedCA)
+
+	var mu sync.Mutex
+	var presented [32]byte
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		presented = sha256.Sum256(r.TLS.PeerCertificates[0].Raw)
+		mu.Unlock()
+		w.Write([]byte(`{"value": "value"}`))
+	}))
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	client, err := NewAPIClientWithReloadingMTLS(srv.URL, testSecret, clientTLS)
+	if err != nil {
+		t.Fatalf("NewAPIClientWithReloadingMTLS: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Retrieve("key"); err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if presented != certFileSum(t, clientTLS.ClientCertFile) {
+		t.Fatal("server did not see the original client certificate")
+	}
+
+	for src, dst := range map[string]string{rotatedTLS.ClientCertFile: clientTLS.ClientCertFile, rotatedTLS.ClientKeyFile: clientTLS.ClientKeyFile} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("read %s: %v", src, err)
+		}
+		if err := os.WriteFile(dst, data, 0o600); err != nil {
+			t.Fatalf("write %s: %v", dst, err)
+		}
+		// Make sure the modification time moves even on coarse clocks.
+		later := time.Now().Add(time.Minute)
+		os.Chtimes(dst, later, later)
+	}
+	// Drop the kept-alive connection so the next request handshakes again.
+	client.Close()
+
+	if _, err := client.Retrieve("key"); err != nil {
+		t.Fatalf("Retrieve after rotation: %v", err)
+	}
+	if presented != certFileSum(t, rotatedTLS.ClientCertFile) {
+		t.Error("server did not see the rotated client certificate")
+	}
+}
+
+func TestReloadingMTLSPinning(t *testing.T) {
+	serverTLS, clientTLS := tlsServerConfig(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value": "value"}`))
+	}))
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	pinned := copyTLSConfig(clientTLS)
+	pinned.PinnedServerCertSHA256 = [][32]byte{sha256.Sum256(serverTLS.Certificates[0].Certificate[0])}
+	client, err := NewAPIClientWithReloadingMTLS(srv.URL, testSecret, pinned)
+	if err != nil {
+		t.Fatalf("NewAPIClientWithReloadingMTLS: %v", err)
+	}
+	if _, err := client.Retrieve("key"); err != nil {
+		t.Errorf("Retrieve with a matching pin: %v", err)
+	}
+
+	mismatched := copyTLSConfig(clientTLS)
+	mismatched.PinnedServerCertSHA256 = [][32]byte{sha256.Sum256([]byte("another certificate"))}
+	client, err = NewAPIClientWithReloadingMTLS(srv.URL, testSecret, mismatched)
+	if err != nil {
+		t.Fatalf("NewAPIClientWithReloadingMTLS: %v", err)
+	}
+	if _, err := client.Retrieve("key"); err == nil || !strings.Contains(err.Error(), "matches no pinned certificate") {
+		t.Errorf("Retrieve with a mismatched pin = %v, want a pin error", err)
+	}
+
+	if _, err := NewAPIClientWithReloadingMTLS(srv.URL, testSecret, &TLSConfig{CAFile: clientTLS.CAFile}); err == nil {
+		t.Error("NewAPIClientWithReloadingMTLS accepted a config without a client certificate")
+	}
+}
+
+// certFileSum returns the SHA-256 of the certificate in a PEM file.
+func certFileSum(t *testing.T, certFile string) [32]byte {
+	t.Helper()
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("read %s: %v", certFile, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("no certificate in %s", certFile)
+	}
+	return sha256.Sum256(block.Bytes)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// SecretString holds a retrieved secret that redacts itself when printed,
+// formatted with %#v or marshalled to JSON. Use Reveal to get the value.
+type SecretString struct {
+	value string
+}
+
+func NewSecretString(value string) SecretString {
+	return SecretString{value: value}
+}
+
+// Reveal returns the underlying secret value.
+func (s SecretString) Reveal() string {
+	return s.value
+}
+
+func (s SecretString) String() string {
+	return "****"
+}
+
+func (s SecretString) GoString() string {
+	return "****"
+}
+
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"****"`), nil
+}
+
+// secretContextKey is the context key under which ContextWithSecret stores
+// the secret.
+type secretContextKey struct{}
+
+// ContextWithSecret returns a copy of ctx carrying secret, for request-scoped
+// code that calls RetrieveCtxSecret. The secret is stored as a SecretString,
+// so a context printed by a logger does not reveal it.
+func ContextWithSecret(ctx context.Context, secret string) context.Context {
+	return context.WithValue(ctx, secretContextKey{}, NewSecretString(secret))
+}
+
+// SecretFromContext returns the secret attached with ContextWithSecret.
+func SecretFromContext(ctx context.Context) (string, bool) {
+	secret, ok := ctx.Value(secretContextKey{}).(SecretString)
+	return secret.Reveal(), ok
+}
+
+// RetrieveCtxSecret retrieves key with the secret attached to ctx by
+// ContextWithSecret.
+func (c *Client) RetrieveCtxSecret(ctx context.Context, key string) (string, error) {
+	secret, ok := SecretFromContext(ctx)
+	if !ok {
+		return "", errors.New("no secret in context")
+	}
+	return c.RetrieveContext(ctx, key, secret)
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretString(t *testing.T) {
+	secret := NewSecretString("hunter2")
+	for _, format := range []string{"%v", "%+v", "%s", "%#v"} {
+		if out := fmt.Sprintf(format, secret); strings.Contains(out, "hunter2") {
+			t.Errorf("%s formatting revealed the secret: %s", format, out)
+		}
+	}
+	out, err := json.Marshal(struct{ Password SecretString }{secret})
+	if err != nil || strings.Contains(string(out), "hunter2") {
+		t.Errorf("json.Marshal = %s, %v", out, err)
+	}
+	if secret.Reveal() != "hunter2" {
+		t.Errorf("Reveal = %q", secret.Reveal())
+	}
+}
+
+func TestContextWithSecret(t *testing.T) {
+	if _, ok := SecretFromContext(context.Background()); ok {
+		t.Error("SecretFromContext found a secret in an empty context")
+	}
+	ctx := ContextWithSecret(context.Background(), testSecret)
+	if secret, ok := SecretFromContext(ctx); !ok || secret != testSecret {
+		t.Errorf("SecretFromContext = %q, %v", secret, ok)
+	}
+	if printed := fmt.Sprint(ContextWithSecret(context.Background(), "hunter2")); strings.Contains(printed, "hunter2") {
+		t.Errorf("printing the context revealed the secret: %s", printed)
+	}
+
+	s := startMockServer(t)
+	s.set("key", "value")
+	c := s.newClient(t)
+	if value, err := c.RetrieveCtxSecret(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("RetrieveCtxSecret = %q, %v", value, err)
+	}
+	if _, err := c.RetrieveCtxSecret(context.Background(), "key"); err == nil {
+		t.Error("RetrieveCtxSecret without a secret succeeded")
+	}
+}
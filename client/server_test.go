@@ -0,0 +1,309 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testSecret is the secret the mock server accepts unless a test changes it.
+const testSecret = "secret"
+
+// testMasterPassword is the master password the mock server requires to
+// grant access with AddAccess.
+const testMasterPassword = "master"
+
+// mockServer is an in-memory ParameterStoreServer. Its hooks must be set
+// before the first call is made.
+type mockServer struct {
+	pb.UnimplementedParameterStoreServer
+
+	mu       sync.Mutex
+	values   map[string]string
+	versions map[string]int
+	// secrets are the accepted secrets; keySecrets overrides them for
+	// individual keys.
+	secrets    []string
+	keySecrets map[string]string
+	// grants are the extra secrets given access to a key with AddAccess.
+	grants map[string][]string
+	// secretHeader, when set, makes the server read the secret from this
+	// metadata header instead of the request password.
+	secretHeader string
+
+	// beforeRetrieve, if set, runs at the start of every Retrieve and can
+	// block or fail it.
+	beforeRetrieve func(ctx context.Context, req *pb.RetrieveRequest) error
+	// mangle, if set, rewrites every value before it is stored.
+	mangle func(string) string
+
+	down      atomic.Bool
+	retrieves atomic.Int64
+	stores    atomic.Int64
+	dials     atomic.Int64
+	// chunks counts the StoreStream chunks received and aborts the streams
+	// that ended with an error instead of a clean close.
+	chunks atomic.Int64
+	aborts atomic.Int64
+
+	lis *bufconn.Listener
+}
+
+func newMockServer() *mockServer {
+	return &mockServer{
+		values:   make(map[string]string),
+		versions: make(map[string]int),
+		secrets:  []string{testSecret},
+		grants:   make(map[string][]string),
+	}
+}
+
+// startMockServer serves a new mockServer on an in-memory bufconn listener.
+func startMockServer(t *testing.T) *mockServer {
+	t.Helper()
+	s := newMockServer()
+	s.lis = bufconn.Listen(1 << 20)
+	serve(t, s, s.lis)
+	return s
+}
+
+// startTLSMockServer serves a new mockServer with mutual TLS on a loopback
+// TCP port and returns the port and a client TLSConfig trusted by it.
+func startTLSMockServer(t *testing.T) (*mockServer, int, *TLSConfig) {
+	t.Helper()
+	serverTLS, clientTLS := tlsServerConfig(t)
+	s := newMockServer()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serve(t, s, lis, grpc.Creds(credentials.NewTLS(serverTLS)))
+	return s, lis.Addr().(*net.TCPAddr).Port, clientTLS
+}
+
+func serve(t *testing.T, s *mockServer, lis net.Listener, opts ...grpc.ServerOption) {
+	t.Helper()
+	srv := grpc.NewServer(opts...)
+	pb.RegisterParameterStoreServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+}
+
+// dial connects to the bufconn listener, counting the connections made.
+func (s *mockServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+	s.dials.Add(1)
+	return s.lis.DialContext(ctx)
+}
+
+// newClient returns a Client connected to s through its bufconn listener.
+func (s *mockServer) newClient(t *testing.T, opts ...ClientOption) *Client {
+	t.Helper()
+	opts = append([]ClientOption{WithContextDialer(s.dial)}, opts...)
+	c, err := NewClient("bufnet", 1, opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.Timeout = 5 * time.Second
+	t.Cleanup(func() { c.Close(context.Background()) })
+	return c
+}
+
+func (s *mockServer) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.versions[key]++
+}
+
+func (s *mockServer) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *mockServer) authorize(ctx context.Context, key, password string) error {
+	if s.down.Load() {
+		return status.Error(codes.Unavailable, "server is down")
+	}
+	if s.secretHeader != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		password = strings.Join(md.Get(s.secretHeader), "")
+	}
+	if want, ok := s.keySecrets[key]; ok {
+		if password != want {
+			return status.Error(codes.Unauthenticated, "wrong secret")
+		}
+		return nil
+	}
+	s.mu.Lock()
+	granted := slices.Contains(s.grants[key], password)
+	s.mu.Unlock()
+	if granted || slices.Contains(s.secrets, password) {
+		return nil
+	}
+	return status.Error(codes.Unauthenticated, "wrong secret")
+}
+
+func (s *mockServer) Store(ctx context.Context, req *pb.StoreRequest) (*pb.StoreResponse, error) {
+	if err := s.authorize(ctx, req.GetKey(), req.GetPassword()); err != nil {
+		return nil, err
+	}
+	s.stores.Add(1)
+	value := req.GetValue()
+	if s.mangle != nil {
+		value = s.mangle(value)
+	}
+	s.set(req.GetKey(), value)
+	return &pb.StoreResponse{Message: "stored " + req.GetKey()}, nil
+}
+
+func (s *mockServer) Retrieve(ctx context.Context, req *pb.RetrieveRequest) (*pb.RetrieveResponse, error) {
+	s.retrieves.Add(1)
+	if s.beforeRetrieve != nil {
+		if err := s.beforeRetrieve(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.authorize(ctx, req.GetKey(), req.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	value, ok := s.values[req.GetKey()]
+	version := s.versions[req.GetKey()]
+	s.mu.Unlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", req.GetKey())
+	}
+	grpc.SetTrailer(ctx, metadata.Pairs("x-value-version", strconv.Itoa(version)))
+	return &pb.RetrieveResponse{
+		Value:         value,
+		Version:       strconv.Itoa(version),
+		UpdatedAtUnix: 1700000000,
+	}, nil
+}
+
+func (s *mockServer) AddAccess(ctx context.Context, req *pb.AddAccessRequest) (*pb.AddAccessResponse, error) {
+	if req.GetMasterPassword() != testMasterPassword {
+		return nil, status.Error(codes.PermissionDenied, "wrong master password")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[req.GetKey()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q not found", req.GetKey())
+	}
+	s.grants[req.GetKey()] = append(s.grants[req.GetKey()], req.GetPassword())
+	return &pb.AddAccessResponse{Message: "granted access to " + req.GetKey()}, nil
+}
+
+func (s *mockServer) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	if err := s.authorize(ctx, "", req.GetPassword()); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.values {
+		if strings.HasPrefix(key, req.GetPrefix()) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return &pb.ListResponse{Keys: keys}, nil
+}
+
+// StoreBatch applies the batch atomically and rejects it as a whole if any
+// item has an empty key.
+func (s *mockServer) StoreBatch(ctx context.Context, req *pb.StoreBatchRequest) (*pb.StoreBatchResponse, error) {
+	if err := s.authorize(ctx, "", req.GetPassword()); err != nil {
+		return nil, err
+	}
+	for _, item := range req.GetItems() {
+		if item.GetKey() == "" {
+			return nil, status.Error(codes.InvalidArgument, "empty key in batch")
+		}
+	}
+	for _, item := range req.GetItems() {
+		s.set(item.GetKey(), item.GetValue())
+	}
+	return &pb.StoreBatchResponse{Message: "stored batch"}, nil
+}
+
+func (s *mockServer) StoreStream(stream grpc.ClientStreamingServer[pb.StoreChunk, pb.StoreResponse]) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(stream.Context(), first.GetKey(), first.GetPassword()); err != nil {
+		return err
+	}
+	s.chunks.Add(1)
+	var value strings.Builder
+	value.Write(first.GetData())
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			s.aborts.Add(1)
+			return err
+		}
+		s.chunks.Add(1)
+		value.Write(chunk.GetData())
+	}
+	s.stores.Add(1)
+	s.set(first.GetKey(), value.String())
+	return stream.SendAndClose(&pb.StoreResponse{Message: "stored " + first.GetKey()})
+}
+
+// RetrieveStream sends the value in chunks of streamChunkSize bytes.
+func (s *mockServer) RetrieveStream(req *pb.RetrieveRequest, stream grpc.ServerStreamingServer[pb.ValueChunk]) error {
+	if err := s.authorize(stream.Context(), req.GetKey(), req.GetPassword()); err != nil {
+		return err
+	}
+	value, ok := s.get(req.GetKey())
+	if !ok {
+		return status.Errorf(codes.NotFound, "key %q not found", req.GetKey())
+	}
+	for len(value) > 0 {
+		n := min(len(value), streamChunkSize)
+		if err := stream.Send(&pb.ValueChunk{Data: []byte(value[:n])}); err != nil {
+			return err
+		}
+		value = value[n:]
+	}
+	return nil
+}
+
+// tlsServerConfig returns a mutual TLS server config and a client TLSConfig
+// trusted by it, removing the client's files when the test ends.
+func tlsServerConfig(t *testing.T) (*tls.Config, *TLSConfig) {
+	t.Helper()
+	serverTLS, clientTLS, cleanup, err := GenerateTestTLSConfig()
+	if err != nil {
+		t.Fatalf("GenerateTestTLSConfig: %v", err)
+	}
+	t.Cleanup(cleanup)
+	return serverTLS, clientTLS
+}
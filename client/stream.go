@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	pb "github.com/Suhaibinator/SuhaibParameterStoreClient/proto"
+)
+
+// streamChunkSize is the size of each frame sent by StoreLarge.
+const streamChunkSize = 64 * 1024
+
+// StoreLarge streams the contents of r to the server in fixed-size chunks,
+// for values too large for a single Store message. Value encoders set with
+// WithValueEncoder are not applied. It fails with ErrStreamEncryption on a
+// client with WithKeyProvider, since the stream would be stored unencrypted.
+func (c *Client) StoreLarge(key, secret string, r io.Reader) (err error) {
+	start := time.Now()
+	defer func() { c.audit("store_large", key, start, err) }()
+	if c.readOnly {
+		return ErrReadOnly
+	}
+	if c.keyProvider != nil {
+		return ErrStreamEncryption
+	}
+	return c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+		// Cancelling the stream aborts the RPC, so a failed read never
+		// leaves a truncated value on the server the way CloseSend would.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ctx, password := c.authenticate(ctx, secret)
+		stream, err := client.StoreStream(ctx)
+		if err != nil {
+			return err
+		}
+
+		buf := make([]byte, streamChunkSize)
+		first := true
+		for {
+			n, readErr := io.ReadFull(r, buf)
+			if n > 0 || first {
+				chunk := &pb.StoreChunk{Data: buf[:n]}
+				if first {
+					chunk.Key = c.keyPrefix + key
+					chunk.Password = password
+					first = false
+				}
+				if err := stream.Send(chunk); err != nil {
+					// The server's reason for aborting is reported by
+					// CloseAndRecv.
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					return err
+				}
+			}
+			if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+				break
+			}
+			if readErr != nil {
+				cancel()
+				return fmt.Errorf("failed to read value for key %q: %w", key, readErr)
+			}
+		}
+
+		_, err = stream.CloseAndRecv()
+		return err
+	})
+}
+
+// RetrieveLarge streams the value of key from the server into w. Value
+// transformers set with WithValueTransformer are not applied. It fails with
+// ErrStreamEncryption on a client with WithKeyProvider, since the value would
+// be written out undecrypted.
+func (c *Client) RetrieveLarge(key, secret string, w io.Writer) (err error) {
+	start := time.Now()
+	defer func() { c.audit("retrieve_large", key, start, err) }()
+	if c.keyProvider != nil {
+		return ErrStreamEncryption
+	}
+	return c.invoke(context.Background(), func(ctx context.Context, client pb.ParameterStoreClient) error {
+		ctx, password := c.authenticate(ctx, secret)
+		stream, err := client.RetrieveStream(ctx, &pb.RetrieveRequest{
+			Key:      c.keyPrefix + key,
+			Password: password,
+		})
+		if err != nil {
+			return err
+		}
+
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(chunk.GetData()); err != nil {
+				return fmt.Errorf("failed to write value for key %q: %w", key, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStoreLargeRetrieveLargeRoundTrip(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	// Three and a half chunks, so both directions span several messages.
+	value := strings.Repeat("0123456789abcdef", 7*streamChunkSize/32)
+	if err := c.StoreLarge("large", testSecret, strings.NewReader(value)); err != nil {
+		t.Fatalf("StoreLarge: %v", err)
+	}
+	if n := s.chunks.Load(); n != 4 {
+		t.Errorf("server received %d chunks, want 4", n)
+	}
+
+	var buf bytes.Buffer
+	if err := c.RetrieveLarge("large", testSecret, &buf); err != nil {
+		t.Fatalf("RetrieveLarge: %v", err)
+	}
+	if buf.String() != value {
+		t.Errorf("RetrieveLarge returned %d bytes, want the %d bytes stored", buf.Len(), len(value))
+	}
+}
+
+func TestStoreLargeEmptyValue(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	if err := c.StoreLarge("empty", testSecret, strings.NewReader("")); err != nil {
+		t.Fatalf("StoreLarge: %v", err)
+	}
+	if value, ok := s.get("empty"); !ok || value != "" {
+		t.Errorf("stored value = %q, %v; want an empty value", value, ok)
+	}
+}
+
+// failingReader returns err once data has been read and wait returns.
+type failingReader struct {
+	data io.Reader
+	wait func()
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.wait()
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestStoreLargeReadErrorStoresNothing(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	readErr := errors.New("disk went away")
+	r := &failingReader{
+		data: strings.NewReader(strings.Repeat("x", 2*streamChunkSize)),
+		// Fail only once the server has received the value so far.
+		wait: func() { waitFor(t, func() bool { return s.chunks.Load() == 2 }) },
+		err:  readErr,
+	}
+	if err := c.StoreLarge("key", testSecret, r); !errors.Is(err, readErr) {
+		t.Fatalf("StoreLarge = %v, want the read error", err)
+	}
+	waitFor(t, func() bool { return s.aborts.Load() == 1 })
+	if value, ok := s.get("key"); ok {
+		t.Errorf("server stored %d bytes of a failed stream", len(value))
+	}
+}
+
+func TestStreamingErrors(t *testing.T) {
+	s := startMockServer(t)
+	c := s.newClient(t)
+
+	if err := c.StoreLarge("key", "wrong", strings.NewReader("value")); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("StoreLarge with wrong secret = %v, want ErrUnauthenticated", err)
+	}
+	var buf bytes.Buffer
+	if err := c.RetrieveLarge("missing", testSecret, &buf); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RetrieveLarge of missing key = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GenerateTestTLSConfig creates a throwaway CA with a server and a client
+// certificate signed by it, for spinning up a real mutual TLS server in
+// tests. The server config requires and verifies client certificates; the
+// client TLSConfig points at PEM files in a temporary directory and expects
+// the server name "localhost". Call cleanup to remove the files.
+//
+// The keys are generated on every call and are not suitable for production.
+func GenerateTestTLSConfig() (server *tls.Config, client *TLSConfig, cleanup func(), err error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "parameter store test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	serverCert, _, _, err := issueTestCert(caCert, caKey, 2, x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	_, clientCertPEM, clientKeyPEM, err := issueTestCert(caCert, caKey, 3, x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "parameter-store-tls-")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	client = &TLSConfig{
+		ClientCertFile: filepath.Join(dir, "client.crt"),
+		ClientKeyFile:  filepath.Join(dir, "client.key"),
+		CAFile:         filepath.Join(dir, "ca.crt"),
+		ServerName:     "localhost",
+	}
+	files := map[string][]byte{
+		client.ClientCertFile: clientCertPEM,
+		client.ClientKeyFile:  clientKeyPEM,
+		client.CAFile:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			cleanup()
+			return nil, nil, nil, err
+		}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+	server = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	return server, client, cleanup, nil
+}
+
+// issueTestCert creates a leaf certificate for localhost signed by the CA and
+// returns it both as a tls.Certificate and PEM encoded.
+func issueTestCert(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, serial int64, usage x509.ExtKeyUsage) (tls.Certificate, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, nil, nil, err
+	}
+	return cert, certPEM, keyPEM, nil
+}
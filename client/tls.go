@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PasswordCallback returns the password protecting a PKCS#12 file.
+type PasswordCallback func() (string, error)
+
+// TLSConfig holds the file paths used to secure the gRPC connection. When a
+// client certificate and key are set the connection uses mutual TLS; CAFile
+// replaces the system roots for verifying the server.
+//
+// Alternatively the client certificate, key and CA chain can come from an
+// in-memory PKCS#12 bundle in P12Bytes, unlocked by P12PasswordFn.
+//
+// The files are loaded by the first GetTLSConfig call and the result is
+// cached, so a TLSConfig shared by several Clients is read once. Call Reset
+// after rotating the files to have them loaded again.
+type TLSConfig struct {
+	ClientCertFile string
+	ClientKeyFile  string
+	CAFile         string
+	ServerName     string
+
+	P12Bytes      []byte
+	P12PasswordFn PasswordCallback
+
+	// CAReadRetries is how many more times reading CAFile is attempted,
+	// CAReadRetryDelay apart, before failing. This rides out a CA bundle
+	// that briefly disappears while being atomically replaced.
+	CAReadRetries    int
+	CAReadRetryDelay time.Duration
+	// CAReadBackoff, when its MaxRetries is set, replaces CAReadRetries and
+	// CAReadRetryDelay with a growing delay between attempts.
+	CAReadBackoff Backoff
+
+	// PinnedServerCertSHA256, when set, additionally requires the SHA-256
+	// of the server's leaf certificate (DER) to match one of these pins, so
+	// a certificate from a compromised CA is still rejected. It applies to
+	// both the gRPC and REST clients built from this TLSConfig.
+	PinnedServerCertSHA256 [][32]byte
+
+	mu     sync.Mutex
+	cached *tls.Config
+}
+
+func (t *TLSConfig) String() string {
+	return fmt.Sprintf("TLSConfig{ClientCertFile:%s ClientKeyFile:%s CAFile:%s ServerName:%s P12Bytes:%d bytes}",
+		t.ClientCertFile, t.ClientKeyFile, t.CAFile, t.ServerName, len(t.P12Bytes))
+}
+
+// NewTLSConfigFromP12Base64 creates a TLSConfig from a base64-encoded PKCS#12
+// bundle, such as one passed through an environment variable. Whitespace in
+// b64 is ignored. The bundle is decoded, and passwordFn called, on the first
+// GetTLSConfig call.
+func NewTLSConfigFromP12Base64(b64 string, passwordFn PasswordCallback) (*TLSConfig, error) {
+	p12Bytes, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(b64), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 P12 data: %w", err)
+	}
+	if len(p12Bytes) == 0 {
+		return nil, errors.New("P12 data is empty")
+	}
+	return &TLSConfig{
+		P12Bytes:      p12Bytes,
+		P12PasswordFn: passwordFn,
+	}, nil
+}
+
+func (t *TLSConfig) Validate() error {
+	if len(t.P12Bytes) > 0 {
+		if t.ClientCertFile != "" || t.ClientKeyFile != "" {
+			return errors.New("P12 data cannot be combined with client certificate files")
+		}
+		if t.P12PasswordFn == nil {
+			return errors.New("a password callback is required for P12 data")
+		}
+	}
+	if (t.ClientCertFile == "") != (t.ClientKeyFile == "") {
+		return errors.New("client certificate and key must be provided together")
+	}
+	paths := []string{t.ClientCertFile, t.ClientKeyFile}
+	if t.caReadBackoff().MaxRetries == 0 {
+		// With retries the CA file may legitimately be missing for a
+		// moment; reading it reports the error instead.
+		paths = append(paths, t.CAFile)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("TLS file %s is not accessible: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// GetTLSConfig returns a *tls.Config built from the configured files. It is
+// safe for concurrent use; the files are only read on the first call after
+// construction or Reset.
+func (t *TLSConfig) GetTLSConfig() (*tls.Config, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cached == nil {
+		tlsConfig, err := t.load()
+		if err != nil {
+			return nil, err
+		}
+		t.cached = tlsConfig
+	}
+	return t.cached.Clone(), nil
+}
+
+// Reset drops the cached configuration so the next GetTLSConfig call reads
+// the files again.
+func (t *TLSConfig) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cached = nil
+}
+
+func (t *TLSConfig) load() (*tls.Config, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if len(t.P12Bytes) > 0 {
+		var err error
+		tlsConfig, err = decodeP12(t.P12Bytes, t.P12PasswordFn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tlsConfig.ServerName = t.ServerName
+
+	if t.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.ClientCertFile, t.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.CAFile != "" {
+		caPEM, err := t.readCAFile()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(t.PinnedServerCertSHA256) > 0 {
+		tlsConfig.VerifyPeerCertificate = verifyPinnedCert(t.PinnedServerCertSHA256)
+	}
+
+	return tlsConfig, nil
+}
+
+func verifyPinnedCert(pins [][32]byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server presented no certificate")
+		}
+		sum := sha256.Sum256(rawCerts[0])
+		for _, pin := range pins {
+			if subtle.ConstantTimeCompare(sum[:], pin[:]) == 1 {
+				return nil
+			}
+		}
+		return fmt.Errorf("server certificate SHA-256 %x matches no pinned certificate", sum)
+	}
+}
+
+func decodeP12(p12Data []byte, passwordFn PasswordCallback) (*tls.Config, error) {
+	password, err := passwordFn()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get P12 password: %w", err)
+	}
+	key, cert, caCerts, err := pkcs12.DecodeChain(p12Data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode P12 data: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{cert.Raw},
+			PrivateKey:  key,
+			Leaf:        cert,
+		}},
+		MinVersion: tls.VersionTLS12,
+	}
+	if len(caCerts) > 0 {
+		pool := x509.NewCertPool()
+		for _, caCert := range caCerts {
+			pool.AddCert(caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// caReadBackoff returns the policy for retrying CA file reads: CAReadBackoff
+// if set, otherwise a fixed CAReadRetryDelay between CAReadRetries retries.
+func (t *TLSConfig) caReadBackoff() Backoff {
+	if t.CAReadBackoff.MaxRetries > 0 {
+		return t.CAReadBackoff
+	}
+	return Backoff{MaxRetries: t.CAReadRetries, Base: t.CAReadRetryDelay, Factor: 1}
+}
+
+func (t *TLSConfig) readCAFile() ([]byte, error) {
+	var caPEM []byte
+	err := t.caReadBackoff().retry(context.Background(), func(error) bool { return true }, func() error {
+		var err error
+		caPEM, err = os.ReadFile(t.CAFile)
+		return err
+	})
+	return caPEM, err
+}
@@ -0,0 +1,422 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestTLSRoundTrip(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	c := newTLSClient(t, port, clientTLS)
+
+	if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+		t.Errorf("Retrieve over mutual TLS = %q, %v", value, err)
+	}
+}
+
+func TestPinnedServerCert(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	pin := serverCertPin(t, port, clientTLS)
+
+	tests := []struct {
+		name    string
+		pin     [32]byte
+		wantErr bool
+	}{
+		{"matching pin", pin, false},
+		{"non-matching pin", sha256.Sum256([]byte("another certificate")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinned := copyTLSConfig(clientTLS)
+			pinned.PinnedServerCertSHA256 = [][32]byte{tt.pin}
+			c := newTLSClient(t, port, pinned)
+
+			_, err := c.Retrieve("key", testSecret)
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "matches no pinned certificate")) {
+				t.Errorf("Retrieve = %v, want a pin mismatch", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Retrieve: %v", err)
+			}
+		})
+	}
+}
+
+func TestConnectionErrorDescribesTLS(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	wrongName := copyTLSConfig(clientTLS)
+	wrongName.ServerName = "wrong.example"
+	c := newTLSClient(t, port, wrongName)
+
+	_, err := c.Retrieve("key", testSecret)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Retrieve = %v, want ErrUnavailable", err)
+	}
+	for _, want := range []string{"localhost:" + strconv.Itoa(port), "TLS", `server name "wrong.example"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+}
+
+func TestWithServerName(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	wrongName := copyTLSConfig(clientTLS)
+	wrongName.ServerName = "wrong.example"
+	c := newTLSClient(t, port, wrongName)
+
+	value, err := c.RetrieveContext(context.Background(), "key", testSecret, WithServerName("localhost"))
+	if err != nil || value != "value" {
+		t.Errorf("RetrieveContext with WithServerName = %q, %v", value, err)
+	}
+	if wrongName.ServerName != "wrong.example" {
+		t.Errorf("WithServerName changed the client's server name to %q", wrongName.ServerName)
+	}
+	if _, err := c.Retrieve("key", testSecret); err == nil {
+		t.Error("Retrieve without the override succeeded, so the override leaked into the client")
+	}
+}
+
+func TestVerifyMTLS(t *testing.T) {
+	_, port, clientTLS := startTLSMockServer(t)
+	c := newTLSClient(t, port, clientTLS)
+	if err := c.VerifyMTLS(context.Background()); err != nil {
+		t.Errorf("VerifyMTLS against a server trusting the client: %v", err)
+	}
+
+	// The client trusts this server, but the server only accepts client
+	// certificates from another CA.
+	serverTLS, trustedClientTLS := tlsServerConfig(t)
+	otherServerTLS, _ := tlsServerConfig(t)
+	serverTLS.ClientCAs = otherServerTLS.ClientCAs
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	serve(t, newMockServer(), lis, grpc.Creds(credentials.NewTLS(serverTLS)))
+	rejected := newTLSClient(t, lis.Addr().(*net.TCPAddr).Port, trustedClientTLS)
+	if err := rejected.VerifyMTLS(context.Background()); err == nil {
+		t.Error("VerifyMTLS succeeded against a server rejecting the client certificate")
+	}
+
+	insecure, err := NewClient("localhost", port)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := insecure.VerifyMTLS(context.Background()); err == nil {
+		t.Error("VerifyMTLS succeeded on an insecure client")
+	}
+}
+
+func TestStrictCertValidation(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	c, err := NewClient("localhost", 8443, WithTLS(clientTLS), WithStrictCertValidation())
+	if err != nil {
+		t.Fatalf("NewClient with a valid certificate: %v", err)
+	}
+
+	// The test certificate is valid from an hour ago for a day.
+	if err := c.checkClientCertValidity(time.Now().Add(48 * time.Hour)); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("check after NotAfter = %v, want an expiry error", err)
+	}
+	if err := c.checkClientCertValidity(time.Now().Add(-48 * time.Hour)); err == nil || !strings.Contains(err.Error(), "not valid until") {
+		t.Errorf("check before NotBefore = %v, want a not-yet-valid error", err)
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := map[string]*TLSConfig{
+		"cert without key":       {ClientCertFile: clientTLS.ClientCertFile},
+		"key without cert":       {ClientKeyFile: clientTLS.ClientKeyFile},
+		"missing CA file":        {CAFile: missing},
+		"missing cert file":      {ClientCertFile: missing, ClientKeyFile: clientTLS.ClientKeyFile},
+		"P12 with cert files":    {P12Bytes: []byte{1}, P12PasswordFn: staticPassword("pw"), ClientCertFile: clientTLS.ClientCertFile, ClientKeyFile: clientTLS.ClientKeyFile},
+		"P12 without a password": {P12Bytes: []byte{1}},
+	}
+	for name, config := range tests {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: Validate succeeded", name)
+		}
+	}
+	if err := clientTLS.Validate(); err != nil {
+		t.Errorf("Validate of a complete config: %v", err)
+	}
+}
+
+func TestCAFileAppearsDuringRetries(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	caPEM, err := os.ReadFile(clientTLS.CAFile)
+	if err != nil {
+		t.Fatalf("read CA: %v", err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	config := &TLSConfig{CAFile: caFile, CAReadRetries: 20, CAReadRetryDelay: 10 * time.Millisecond}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		// Replace atomically, as a secret-mounting agent would.
+		os.WriteFile(caFile+".tmp", caPEM, 0o600)
+		os.Rename(caFile+".tmp", caFile)
+	}()
+	tlsConfig, err := config.GetTLSConfig()
+	if err != nil {
+		t.Fatalf("GetTLSConfig: %v", err)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("CA file was not loaded")
+	}
+
+	noRetries := &TLSConfig{CAFile: filepath.Join(t.TempDir(), "ca.crt")}
+	if _, err := noRetries.GetTLSConfig(); err == nil {
+		t.Error("GetTLSConfig succeeded without a CA file")
+	}
+}
+
+func TestGetTLSConfigCachesUntilReset(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	caFile := clientTLS.CAFile
+	config := &TLSConfig{CAFile: caFile}
+	if _, err := config.GetTLSConfig(); err != nil {
+		t.Fatalf("GetTLSConfig: %v", err)
+	}
+
+	if err := os.Remove(caFile); err != nil {
+		t.Fatalf("remove CA: %v", err)
+	}
+	if _, err := config.GetTLSConfig(); err != nil {
+		t.Errorf("GetTLSConfig after the CA file was removed = %v, want the cached config", err)
+	}
+	config.Reset()
+	if _, err := config.GetTLSConfig(); err == nil {
+		t.Error("GetTLSConfig after Reset succeeded without the CA file")
+	}
+}
+
+func TestGetTLSConfigDecodesP12Once(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	p12 := encodeTestP12(t, clientTLS, "pw")
+	var calls atomic.Int32
+	config := &TLSConfig{P12Bytes: p12, P12PasswordFn: func() (string, error) {
+		calls.Add(1)
+		return "pw", nil
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tlsConfig, err := config.GetTLSConfig()
+			if err != nil || len(tlsConfig.Certificates) != 1 {
+				t.Errorf("GetTLSConfig = %v, %v", tlsConfig, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("password callback called %d times, want 1", n)
+	}
+
+	config.Reset()
+	if _, err := config.GetTLSConfig(); err != nil {
+		t.Fatalf("GetTLSConfig after Reset: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("password callback called %d times after Reset, want 2", n)
+	}
+}
+
+func TestNewClientDecodesP12BeforeCalls(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	var calls atomic.Int32
+	config := &TLSConfig{P12Bytes: encodeTestP12(t, clientTLS, "pw"), P12PasswordFn: func() (string, error) {
+		calls.Add(1)
+		return "pw", nil
+	}}
+
+	// Two Clients sharing the config decode it once, in the first NewClient.
+	first := newTLSClient(t, port, config)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("password callback called %d times by NewClient, want 1", n)
+	}
+	second := newTLSClient(t, port, config)
+	for _, c := range []*Client{first, second} {
+		if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+			t.Fatalf("Retrieve = %q, %v", value, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("password callback called %d times, want 1", n)
+	}
+
+	wrongPassword := &TLSConfig{P12Bytes: config.P12Bytes, P12PasswordFn: staticPassword("wrong")}
+	if _, err := NewClient("localhost", port, WithTLS(wrongPassword)); err == nil {
+		t.Error("NewClient succeeded with the wrong P12 password")
+	}
+}
+
+func TestNewTLSConfigFromP12Base64(t *testing.T) {
+	_, clientTLS := tlsServerConfig(t)
+	b64 := base64.StdEncoding.EncodeToString(encodeTestP12(t, clientTLS, "pw"))
+	// Wrap the encoding as it would appear in a multi-line variable.
+	wrapped := b64[:40] + "\n" + b64[40:]
+
+	config, err := NewTLSConfigFromP12Base64(wrapped, staticPassword("pw"))
+	if err != nil {
+		t.Fatalf("NewTLSConfigFromP12Base64: %v", err)
+	}
+	if _, err := config.GetTLSConfig(); err != nil {
+		t.Errorf("GetTLSConfig: %v", err)
+	}
+
+	for name, input := range map[string]string{"malformed": "not base64!", "empty": " \n"} {
+		if _, err := NewTLSConfigFromP12Base64(input, staticPassword("pw")); err == nil {
+			t.Errorf("%s input: NewTLSConfigFromP12Base64 succeeded", name)
+		}
+	}
+	wrongPassword, _ := NewTLSConfigFromP12Base64(b64, staticPassword("wrong"))
+	if _, err := wrongPassword.GetTLSConfig(); err == nil {
+		t.Error("GetTLSConfig succeeded with the wrong password")
+	}
+}
+
+func TestWithTLSFromP12(t *testing.T) {
+	s, port, clientTLS := startTLSMockServer(t)
+	s.set("key", "value")
+	p12File := filepath.Join(t.TempDir(), "client.p12")
+	if err := os.WriteFile(p12File, encodeTestP12(t, clientTLS, "pw"), 0o600); err != nil {
+		t.Fatalf("write P12: %v", err)
+	}
+	var calls atomic.Int32
+	passwordFn := func() (string, error) {
+		calls.Add(1)
+		return "pw", nil
+	}
+
+	c, err := NewClient("localhost", port, WithTLSFromP12(p12File, passwordFn))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("password callback called %d times by NewClient, want 1", n)
+	}
+	c.Timeout = 5 * time.Second
+	for i := 0; i < 2; i++ {
+		if value, err := c.Retrieve("key", testSecret); err != nil || value != "value" {
+			t.Fatalf("Retrieve = %q, %v", value, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("password callback called %d times, want 1", n)
+	}
+	if !strings.Contains(c.Describe(), "TLS mode: P12 data") {
+		t.Errorf("Describe() = %q, want the P12 TLS mode", c.Describe())
+	}
+
+	if _, err := NewClient("localhost", port, WithTLSFromP12(p12File, staticPassword("wrong"))); err == nil {
+		t.Error("NewClient succeeded with the wrong P12 password")
+	}
+	if _, err := NewClient("localhost", port, WithTLSFromP12(p12File, passwordFn), WithTLS(clientTLS)); err == nil {
+		t.Error("NewClient accepted both WithTLSFromP12 and WithTLS")
+	}
+	if _, err := NewClient("localhost", port, WithTLS(clientTLS), WithTLSFromP12(p12File, passwordFn)); err == nil {
+		t.Error("NewClient accepted both WithTLS and WithTLSFromP12")
+	}
+	if _, err := NewClient("localhost", port, WithTLSFromP12(filepath.Join(t.TempDir(), "missing.p12"), passwordFn)); err == nil {
+		t.Error("NewClient succeeded with a missing P12 file")
+	}
+}
+
+func newTLSClient(t *testing.T, port int, tlsConfig *TLSConfig) *Client {
+	t.Helper()
+	c, err := NewClient("localhost", port, WithTLS(tlsConfig))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	c.Timeout = 5 * time.Second
+	return c
+}
+
+// copyTLSConfig copies the file settings of config without its cache.
+func copyTLSConfig(config *TLSConfig) *TLSConfig {
+	return &TLSConfig{
+		ClientCertFile: config.ClientCertFile,
+		ClientKeyFile:  config.ClientKeyFile,
+		CAFile:         config.CAFile,
+		ServerName:     config.ServerName,
+	}
+}
+
+// serverCertPin returns the SHA-256 of the leaf certificate served on port.
+func serverCertPin(t *testing.T, port int, clientTLS *TLSConfig) [32]byte {
+	t.Helper()
+	tlsConfig, err := clientTLS.GetTLSConfig()
+	if err != nil {
+		t.Fatalf("GetTLSConfig: %v", err)
+	}
+	tlsConfig.NextProtos = []string{"h2"}
+	conn, err := tls.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port), tlsConfig)
+	if err != nil {
+		t.Fatalf("TLS dial: %v", err)
+	}
+	defer conn.Close()
+	return sha256.Sum256(conn.ConnectionState().PeerCertificates[0].Raw)
+}
+
+// encodeTestP12 bundles the client certificate, key and CA of clientTLS into
+// a PKCS#12 file protected by password.
+func encodeTestP12(t *testing.T, clientTLS *TLSConfig, password string) []byte {
+	t.Helper()
+	pair, err := tls.LoadX509KeyPair(clientTLS.ClientCertFile, clientTLS.ClientKeyFile)
+	if err != nil {
+		t.Fatalf("load key pair: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	caPEM, err := os.ReadFile(clientTLS.CAFile)
+	if err != nil {
+		t.Fatalf("read CA: %v", err)
+	}
+	block, _ := pem.Decode(caPEM)
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse CA: %v", err)
+	}
+	p12, err := pkcs12.Modern.Encode(pair.PrivateKey, leaf, []*x509.Certificate{ca}, password)
+	if err != nil {
+		t.Fatalf("encode P12: %v", err)
+	}
+	return p12
+}
+
+func staticPassword(password string) PasswordCallback {
+	return func() (string, error) { return password, nil }
+}
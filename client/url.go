@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// NewClientFromURL creates a Client from a service URL such as
+// grpcs://store.example.com:8443. The grpc scheme defaults to port 80 and
+// grpcs to port 443; grpcs also enables TLS with the system roots unless opts
+// configure TLS themselves.
+func NewClientFromURL(raw string, opts ...ClientOption) (*Client, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+
+	var defaultPort int
+	switch u.Scheme {
+	case "grpc":
+		defaultPort = 80
+	case "grpcs":
+		defaultPort = 443
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q in %q, expected grpc or grpcs", u.Scheme, raw)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("URL %q has no host", raw)
+	}
+	port := defaultPort
+	if rawPort := u.Port(); rawPort != "" {
+		port, err = strconv.Atoi(rawPort)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in URL %q: %w", raw, err)
+		}
+	}
+
+	c, err := NewClient(host, port, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "grpcs" && c.TLSConfig == nil {
+		c.TLSConfig = &TLSConfig{}
+	}
+	return c, nil
+}
@@ -0,0 +1,34 @@
+package client
+
+import "testing"
+
+func TestNewClientFromURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		address string
+		tls     bool
+	}{
+		{"grpc://store.example.com:9000", "store.example.com:9000", false},
+		{"grpc://store.example.com", "store.example.com:80", false},
+		{"grpcs://store.example.com", "store.example.com:443", true},
+		{"grpcs://store.example.com:8443", "store.example.com:8443", true},
+		{"grpc://[::1]:50051", "[::1]:50051", false},
+		{"grpc://10.0.0.1", "10.0.0.1:80", false},
+	}
+	for _, tt := range tests {
+		c, err := NewClientFromURL(tt.url)
+		if err != nil {
+			t.Errorf("NewClientFromURL(%q): %v", tt.url, err)
+			continue
+		}
+		if c.address() != tt.address || (c.TLSConfig != nil) != tt.tls {
+			t.Errorf("NewClientFromURL(%q) = address %q, TLS %v; want %q, %v", tt.url, c.address(), c.TLSConfig != nil, tt.address, tt.tls)
+		}
+	}
+
+	for _, raw := range []string{"http://store.example.com", "grpc://", "grpc://store.example.com:port", "grpc://store.example.com:0", "://"} {
+		if _, err := NewClientFromURL(raw); err == nil {
+			t.Errorf("NewClientFromURL(%q) succeeded", raw)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// verifyReadWindow is how long VerifyMTLS waits after the handshake for the
+// server to reject the client certificate. Under TLS 1.3 the server verifies
+// the client certificate after the client considers the handshake complete,
+// so a rejection only arrives as an alert on the first read.
+const verifyReadWindow = 200 * time.Millisecond
+
+// VerifyMTLS performs a TLS handshake with the server, without making an RPC,
+// to confirm that the server certificate is trusted and that the server
+// accepts the client certificate. It dials Host and Port directly, bypassing
+// WithResolver, WithContextDialer and the connection pool. It returns an error
+// if the client is not configured for TLS.
+func (c *Client) VerifyMTLS(ctx context.Context) error {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		return errors.New("client is not configured for TLS")
+	}
+	// gRPC servers refuse connections that do not negotiate HTTP/2.
+	tlsConfig.NextProtos = []string{"h2"}
+
+	network, addr := "tcp", c.hostPort()
+	if c.isUnixSocket() {
+		network, addr = "unix", strings.TrimPrefix(strings.TrimPrefix(c.Host, "unix://"), "unix:")
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = "localhost"
+		}
+	}
+
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return fmt.Errorf("TLS handshake with %s failed: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(time.Now().Add(verifyReadWindow)); err != nil {
+		return err
+	}
+	// Any data, such as the server's HTTP/2 settings, or silence until the
+	// deadline means the server kept the connection.
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("server %s rejected the client certificate: %w", addr, err)
+	}
+	return nil
+}
@@ -3,12 +3,17 @@ module github.com/Suhaibinator/SuhaibParameterStoreClient
 go 1.22.4
 
 require (
+	golang.org/x/net v0.28.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/term v0.23.0
+	golang.org/x/time v0.6.0
 	google.golang.org/grpc v1.67.1
 	google.golang.org/protobuf v1.35.1
+	software.sslmate.com/src/go-pkcs12 v0.7.3
 )
 
 require (
-	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/crypto v0.26.0 // indirect
 	golang.org/x/sys v0.24.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
@@ -1,4 +1,8 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: ParameterStoreClient/proto/parameter_store_interface.proto
 
 package parameterstore
 
@@ -11,8 +15,18 @@ import (
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the grpc package it is being compiled against.
-// Requires gRPC-Go v1.32.0 or later.
-const _ = grpc.SupportPackageIsVersion7
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ParameterStore_Store_FullMethodName          = "/parameterstore.ParameterStore/Store"
+	ParameterStore_Retrieve_FullMethodName       = "/parameterstore.ParameterStore/Retrieve"
+	ParameterStore_AddAccess_FullMethodName      = "/parameterstore.ParameterStore/AddAccess"
+	ParameterStore_List_FullMethodName           = "/parameterstore.ParameterStore/List"
+	ParameterStore_StoreBatch_FullMethodName     = "/parameterstore.ParameterStore/StoreBatch"
+	ParameterStore_StoreStream_FullMethodName    = "/parameterstore.ParameterStore/StoreStream"
+	ParameterStore_RetrieveStream_FullMethodName = "/parameterstore.ParameterStore/RetrieveStream"
+)
 
 // ParameterStoreClient is the client API for ParameterStore service.
 //
@@ -21,6 +35,10 @@ type ParameterStoreClient interface {
 	Store(ctx context.Context, in *StoreRequest, opts ...grpc.CallOption) (*StoreResponse, error)
 	Retrieve(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (*RetrieveResponse, error)
 	AddAccess(ctx context.Context, in *AddAccessRequest, opts ...grpc.CallOption) (*AddAccessResponse, error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	StoreBatch(ctx context.Context, in *StoreBatchRequest, opts ...grpc.CallOption) (*StoreBatchResponse, error)
+	StoreStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StoreChunk, StoreResponse], error)
+	RetrieveStream(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ValueChunk], error)
 }
 
 type parameterStoreClient struct {
@@ -32,8 +50,9 @@ func NewParameterStoreClient(cc grpc.ClientConnInterface) ParameterStoreClient {
 }
 
 func (c *parameterStoreClient) Store(ctx context.Context, in *StoreRequest, opts ...grpc.CallOption) (*StoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(StoreResponse)
-	err := c.cc.Invoke(ctx, "/parameterstore.ParameterStore/Store", in, out, opts...)
+	err := c.cc.Invoke(ctx, ParameterStore_Store_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +60,9 @@ func (c *parameterStoreClient) Store(ctx context.Context, in *StoreRequest, opts
 }
 
 func (c *parameterStoreClient) Retrieve(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (*RetrieveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RetrieveResponse)
-	err := c.cc.Invoke(ctx, "/parameterstore.ParameterStore/Retrieve", in, out, opts...)
+	err := c.cc.Invoke(ctx, ParameterStore_Retrieve_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -50,27 +70,87 @@ func (c *parameterStoreClient) Retrieve(ctx context.Context, in *RetrieveRequest
 }
 
 func (c *parameterStoreClient) AddAccess(ctx context.Context, in *AddAccessRequest, opts ...grpc.CallOption) (*AddAccessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(AddAccessResponse)
-	err := c.cc.Invoke(ctx, "/parameterstore.ParameterStore/AddAccess", in, out, opts...)
+	err := c.cc.Invoke(ctx, ParameterStore_AddAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parameterStoreClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, ParameterStore_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *parameterStoreClient) StoreBatch(ctx context.Context, in *StoreBatchRequest, opts ...grpc.CallOption) (*StoreBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StoreBatchResponse)
+	err := c.cc.Invoke(ctx, ParameterStore_StoreBatch_FullMethodName, in, out, cOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
+func (c *parameterStoreClient) StoreStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[StoreChunk, StoreResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ParameterStore_ServiceDesc.Streams[0], ParameterStore_StoreStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StoreChunk, StoreResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ParameterStore_StoreStreamClient = grpc.ClientStreamingClient[StoreChunk, StoreResponse]
+
+func (c *parameterStoreClient) RetrieveStream(ctx context.Context, in *RetrieveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ValueChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ParameterStore_ServiceDesc.Streams[1], ParameterStore_RetrieveStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RetrieveRequest, ValueChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ParameterStore_RetrieveStreamClient = grpc.ServerStreamingClient[ValueChunk]
+
 // ParameterStoreServer is the server API for ParameterStore service.
 // All implementations must embed UnimplementedParameterStoreServer
-// for forward compatibility
+// for forward compatibility.
 type ParameterStoreServer interface {
 	Store(context.Context, *StoreRequest) (*StoreResponse, error)
 	Retrieve(context.Context, *RetrieveRequest) (*RetrieveResponse, error)
 	AddAccess(context.Context, *AddAccessRequest) (*AddAccessResponse, error)
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	StoreBatch(context.Context, *StoreBatchRequest) (*StoreBatchResponse, error)
+	StoreStream(grpc.ClientStreamingServer[StoreChunk, StoreResponse]) error
+	RetrieveStream(*RetrieveRequest, grpc.ServerStreamingServer[ValueChunk]) error
 	mustEmbedUnimplementedParameterStoreServer()
 }
 
-// UnimplementedParameterStoreServer must be embedded to have forward compatible implementations.
-type UnimplementedParameterStoreServer struct {
-}
+// UnimplementedParameterStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedParameterStoreServer struct{}
 
 func (UnimplementedParameterStoreServer) Store(context.Context, *StoreRequest) (*StoreResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Store not implemented")
@@ -81,7 +161,20 @@ func (UnimplementedParameterStoreServer) Retrieve(context.Context, *RetrieveRequ
 func (UnimplementedParameterStoreServer) AddAccess(context.Context, *AddAccessRequest) (*AddAccessResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddAccess not implemented")
 }
+func (UnimplementedParameterStoreServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedParameterStoreServer) StoreBatch(context.Context, *StoreBatchRequest) (*StoreBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreBatch not implemented")
+}
+func (UnimplementedParameterStoreServer) StoreStream(grpc.ClientStreamingServer[StoreChunk, StoreResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StoreStream not implemented")
+}
+func (UnimplementedParameterStoreServer) RetrieveStream(*RetrieveRequest, grpc.ServerStreamingServer[ValueChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method RetrieveStream not implemented")
+}
 func (UnimplementedParameterStoreServer) mustEmbedUnimplementedParameterStoreServer() {}
+func (UnimplementedParameterStoreServer) testEmbeddedByValue()                        {}
 
 // UnsafeParameterStoreServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to ParameterStoreServer will
@@ -91,6 +184,13 @@ type UnsafeParameterStoreServer interface {
 }
 
 func RegisterParameterStoreServer(s grpc.ServiceRegistrar, srv ParameterStoreServer) {
+	// If the following call pancis, it indicates UnimplementedParameterStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
 	s.RegisterService(&ParameterStore_ServiceDesc, srv)
 }
 
@@ -104,7 +204,7 @@ func _ParameterStore_Store_Handler(srv interface{}, ctx context.Context, dec fun
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/parameterstore.ParameterStore/Store",
+		FullMethod: ParameterStore_Store_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(ParameterStoreServer).Store(ctx, req.(*StoreRequest))
@@ -122,7 +222,7 @@ func _ParameterStore_Retrieve_Handler(srv interface{}, ctx context.Context, dec
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/parameterstore.ParameterStore/Retrieve",
+		FullMethod: ParameterStore_Retrieve_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(ParameterStoreServer).Retrieve(ctx, req.(*RetrieveRequest))
@@ -140,7 +240,7 @@ func _ParameterStore_AddAccess_Handler(srv interface{}, ctx context.Context, dec
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/parameterstore.ParameterStore/AddAccess",
+		FullMethod: ParameterStore_AddAccess_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(ParameterStoreServer).AddAccess(ctx, req.(*AddAccessRequest))
@@ -148,6 +248,60 @@ func _ParameterStore_AddAccess_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ParameterStore_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParameterStoreServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParameterStore_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParameterStoreServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParameterStore_StoreBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ParameterStoreServer).StoreBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ParameterStore_StoreBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ParameterStoreServer).StoreBatch(ctx, req.(*StoreBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ParameterStore_StoreStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ParameterStoreServer).StoreStream(&grpc.GenericServerStream[StoreChunk, StoreResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ParameterStore_StoreStreamServer = grpc.ClientStreamingServer[StoreChunk, StoreResponse]
+
+func _ParameterStore_RetrieveStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RetrieveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ParameterStoreServer).RetrieveStream(m, &grpc.GenericServerStream[RetrieveRequest, ValueChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ParameterStore_RetrieveStreamServer = grpc.ServerStreamingServer[ValueChunk]
+
 // ParameterStore_ServiceDesc is the grpc.ServiceDesc for ParameterStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -167,7 +321,26 @@ var ParameterStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddAccess",
 			Handler:    _ParameterStore_AddAccess_Handler,
 		},
+		{
+			MethodName: "List",
+			Handler:    _ParameterStore_List_Handler,
+		},
+		{
+			MethodName: "StoreBatch",
+			Handler:    _ParameterStore_StoreBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StoreStream",
+			Handler:       _ParameterStore_StoreStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RetrieveStream",
+			Handler:       _ParameterStore_RetrieveStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "ParameterStoreClient/proto/parameter_store_interface.proto",
 }